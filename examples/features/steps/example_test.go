@@ -28,8 +28,9 @@ func init() {
     // Here we see an example of how to match an element of a
     // scenario's step so that we can utilize it in the step's
     // function definition. gorkin will automatically perform the type
-    // conversion.
-    Step(`they should see ([0-9]+) example(?:s)?`, func(t *T, numExamples int) {
+    // conversion. Steps may be written as regexes or, as here, as
+    // Cucumber Expressions.
+    Step(`they should see {int} example(s).`, func(t *T, numExamples int) {
         t.Logf("user should see %d", numExamples)
     })
 }
@@ -86,3 +86,370 @@ Feature: Running Over Feature Files
     """
     ok
     """
+
+  Scenario: A user runs steps registered against their own registry more than once.
+    Given the file "./features/registry.feature" exists with content
+    """
+    Feature: Registry Feature
+
+      Scenario: Scenario A
+        Given set state to "1"
+        Then state should be "1"
+    """
+    And the file "./features/steps/registry_test.go" exists with content
+    """
+    package gorkin
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+
+        type I struct {
+            State int
+        }
+
+        reg := NewRegistry()
+
+        reg.Step(`set state to "([^"]+)"$`, func(i *I, state int) {
+            i.State = state
+        })
+
+        reg.Step(`state should be "([^"]+)"$`, func(i *I, state int) {
+            if i.State != state {
+                t.Fatal("State is not", state)
+            }
+        })
+
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "go test -count=2 ./features/steps/..."
+    Then the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user registers steps with the default registry and runs them twice.
+    Given the file "./features/twice.feature" exists with content
+    """
+    Feature: Twice Feature
+
+      Scenario: Scenario A
+        Given the colour red
+    """
+    And the file "./features/steps/twice_test.go" exists with content
+    """
+    package gorkin
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type Colour string
+
+    type Palette struct {}
+
+    func Test(t *testing.T) {
+        type I struct {
+            Hooks int
+        }
+
+        Provide(func() *Palette { return &Palette{} })
+        BeforeScenario(func(i *I) {
+            i.Hooks++
+        })
+        ParameterType("colour", `red|blue`, func(s string) (Colour, error) {
+            return Colour(s), nil
+        })
+        Step(`the colour {colour}`, func(i *I, p *Palette, c Colour) {
+            if p == nil || c != "red" {
+                t.Fatal("unexpected colour", c)
+            }
+            if i.Hooks != 1 {
+                t.Fatal("BeforeScenario ran", i.Hooks, "times")
+            }
+        })
+
+        RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "go test -count=2 ./features/steps/..."
+    Then the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user writes steps as methods of their isolation type.
+    Given the file "./features/methods.feature" exists with content
+    """
+    Feature: Methods Feature
+
+      Scenario: Scenario A
+        Given set state to 1
+        Then state should be 1
+    """
+    And the file "./features/steps/methods_test.go" exists with content
+    """
+    package gorkin
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type I struct {
+        State int
+    }
+
+    func (i *I) StepPatterns() map[string]string {
+        return map[string]string{
+            "SetState":   `set state to {int}`,
+            "CheckState": `state should be {int}`,
+        }
+    }
+
+    func (i *I) SetState(state int) {
+        i.State = state
+    }
+
+    func (i *I) CheckState(t *testing.T, state int) {
+        if i.State != state {
+            t.Fatal("State is not", state)
+        }
+    }
+
+    func Test(t *testing.T) {
+        reg := NewRegistry()
+        reg.RegisterMethods(&I{})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "go test -count=2 ./features/steps/..."
+    Then the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user runs steps from within other steps.
+    Given the file "./features/nested.feature" exists with content
+    """
+    Feature: Nested Feature
+
+      Scenario: Scenario A
+        Given a logged-in admin
+        Then the user "bob" should be an admin
+
+      Scenario: Scenario B
+        Given a missing user
+
+      Scenario: Scenario C
+        Given a user who can't be saved
+    """
+    And the file "./features/steps/nested_test.go" exists with content
+    """
+    package gorkin
+
+    import (
+        "context"
+        "errors"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+
+        type I struct {
+            Users  map[string]bool
+            Admins map[string]bool
+        }
+
+        reg := NewRegistry()
+
+        reg.Step(`the user {string} exists`, func(i *I, name string) {
+            i.Users = map[string]bool{name: true}
+        })
+        reg.Step(`the user {string} is an admin`, func(t *testing.T, i *I, name string) {
+            if !i.Users[name] {
+                t.Errorf("there is no user %q", name)
+            }
+            i.Admins = map[string]bool{name: true}
+        })
+        reg.Step(`a logged-in admin`, func(ctx context.Context) {
+            if RunStep(ctx, `Given the user "bob" exists`) != nil {
+                return
+            }
+            RunStep(ctx, `And the user "bob" is an admin`)
+        })
+        reg.Step(`a missing user`, func(ctx context.Context) {
+            RunStep(ctx, `Given the user "alice" is an admin`)
+        })
+        reg.Step(`the user {string} is saved`, func(name string) error {
+            return errors.New("the disk is full")
+        })
+        reg.Step(`a user who can't be saved`, func(ctx context.Context) error {
+            return RunStep(ctx, `Given the user "carol" is saved`)
+        })
+        reg.Step(`the user {string} should be an admin`, func(t *testing.T, i *I, name string) {
+            if !i.Admins[name] {
+                t.Errorf("%q is not an admin", name)
+            }
+        })
+
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    there is no user "alice"
+    """
+    And the output should contain
+    """
+    ../nested.feature:8: Given a missing user: nested step "Given the user \"alice\" is an admin": the step defined at nested_test.go:23 failed
+    """
+    And the output should contain
+    """
+    --- FAIL: Test/Scenario_B
+    """
+    And the output should contain exactly once
+    """
+    ../nested.feature:11: Given the user "carol" is saved: the disk is full
+    """
+
+  Scenario: A user marks steps as pending.
+    Given the file "./features/pending.feature" exists with content
+    """
+    Feature: Pending Feature
+
+      Scenario: Scenario A
+        Given a step which isn't written
+        And a step which passes
+
+      Scenario: Scenario B
+        Given a step which panics pending
+
+      Scenario: Scenario C
+        Given a step which returns pending
+    """
+    And the file "./features/steps/pending_test.go" exists with content
+    """
+    package gorkin
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type I struct {}
+
+    func Test(t *testing.T) {
+        reg := NewRegistry()
+        reg.Step(`a step which isn't written`, Pending)
+        reg.Step(`a step which passes`, func() {
+            t.Fatal("the scenario continued after a pending step")
+        })
+        reg.Step(`a step which panics pending`, func() {
+            panic(ErrPending)
+        })
+        reg.Step(`a step which returns pending`, func() error {
+            return ErrPending
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "go test -v ./features/steps/..."
+    Then the output should contain
+    """
+    --- SKIP: Test/Scenario_A
+    """
+    And the output should contain
+    """
+    pending.feature:4: Given a step which isn't written: pending
+    """
+    And the output should contain
+    """
+    --- SKIP: Test/Scenario_B
+    """
+    And the output should contain
+    """
+    --- SKIP: Test/Scenario_C
+    """
+    And the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user checks their features without running any steps.
+    Given the file "./features/dry.feature" exists with content
+    """
+    Feature: Dry Feature
+
+      Scenario: Scenario A
+        Given a step which would panic
+        And a step which isn't written
+        Then the level is 3
+    """
+    And the file "./features/steps/dry_test.go" exists with content
+    """
+    package gorkin
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type I struct {}
+
+    func Test(t *testing.T) {
+        reg := NewRegistry()
+        reg.BeforeScenario(func() {
+            panic("hooks shouldn't run")
+        })
+        reg.Step(`a step which would panic`, func() {
+            panic("steps shouldn't run")
+        })
+        reg.Step(`a step which isn't written`, Pending)
+        reg.Step(`the level is (\w+)`, func(level int8) {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin run -dry-run"
+    Then the command should succeed
+    And the output should contain
+    """
+    ok
+    """
+    Given the file "./features/dry.feature" exists with content
+    """
+    Feature: Dry Feature
+
+      Scenario: Scenario A
+        Given a step which would panic
+        And a step which isn't written
+        Then the level is high
+    """
+    When a user runs "gorkin run -dry-run"
+    Then the command should fail
+    And the output should contain
+    """
+    dry.feature:6: Then the level is high: group 1 ("high"): cannot convert "high" to int8
+    """
+    And the output should contain
+    """
+    dry.feature:5: And a step which isn't written: pending
+    """
+    When a user runs "gorkin run -dry-run -format=junit"
+    Then the output should contain
+    """
+    <testsuite name="Dry Feature"
+    """
@@ -0,0 +1,318 @@
+Feature: Formatters
+  As a gorkin user
+  I would like to choose how the results of a run are reported
+  So that both people and tools can make sense of them.
+
+  Background:
+    Given the path "./features" exists
+    And the path "./features/steps" exists
+
+  Scenario: A user reports a run with their own formatter.
+    Given the file "./features/events.feature" exists with content
+    """
+    Feature: Events Feature
+
+      Scenario: Scenario A
+        Given a step which attaches a note
+        And a step which fails
+        And a step which passes
+    """
+    And the file "./features/steps/events_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "fmt"
+        "io"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type events struct {
+        BaseFormatter
+        w io.Writer
+    }
+
+    func (e *events) SuiteStarted() { fmt.Fprintln(e.w, "suite started") }
+    func (e *events) FeatureStarted(f *FeatureInfo) { fmt.Fprintln(e.w, "feature", f.Name) }
+    func (e *events) ScenarioStarted(s *ScenarioInfo) { fmt.Fprintln(e.w, "scenario", s.Name) }
+    func (e *events) StepFinished(s *StepInfo) {
+        fmt.Fprintln(e.w, "step", s.Text, s.Status, len(s.Attachments))
+    }
+    func (e *events) ScenarioFinished(s *ScenarioInfo) { fmt.Fprintln(e.w, "scenario", s.Status) }
+    func (e *events) SuiteFinished() { fmt.Fprintln(e.w, "suite finished") }
+
+    func init() {
+        RegisterFormatter("events", func(w io.Writer) Formatter {
+            return &events{w: w}
+        })
+    }
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a step which attaches a note`, func(s *StepInfo) {
+            s.Attach("text/plain", []byte("a note"))
+        })
+        reg.Step(`a step which fails`, func(t *testing.T) {
+            t.Fail()
+        })
+        reg.Step(`a step which passes`, func() {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin run -format=events"
+    Then the output should contain
+    """
+    suite started
+    feature Events Feature
+    scenario Scenario A
+    step Given a step which attaches a note passed 1
+    step And a step which fails failed 0
+    step And a step which passes skipped 0
+    scenario failed
+    suite finished
+    """
+
+  Scenario: A user reports a run with the pretty formatter.
+    Given the file "./features/pretty.feature" exists with content
+    """
+    Feature: Pretty Feature
+
+      Scenario: Scenario A
+        Given an account named "bob"
+        And a step which fails
+        And a step which passes
+
+      Scenario: Scenario B
+        Given a step which passes
+
+      Scenario: Scenario C
+        Given a step to write
+    """
+    And the file "./features/steps/pretty_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`an account named {string}`, func(name string) {})
+        reg.Step(`a step which fails`, func() {
+            panic("oops")
+        })
+        reg.Step(`a step which passes`, func() {})
+        reg.Step(`a step to write`, Pending)
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin run -format=pretty"
+    Then the output should contain
+    """
+    Feature: Pretty Feature
+
+      Scenario: Scenario A           # ../pretty.feature:3
+        Given an account named "bob" # pretty_test.go:13
+        And a step which fails       # pretty_test.go:14
+          ../pretty.feature:5: And a step which fails: panic: oops
+    """
+    And the output should contain
+    """
+        And a step which passes      # pretty_test.go:17
+    """
+    And the output should contain
+    """
+    3 scenarios (1 passed, 1 failed, 1 pending), 5 steps (2 passed, 1 failed, 1 pending, 1 skipped)
+    """
+
+  Scenario: A user reports a run as JUnit XML.
+    Given the file "./features/junit.feature" exists with content
+    """
+    Feature: JUnit Feature
+
+      Scenario: Scenario A
+        Given a step which fails
+        And a step which passes
+
+      Scenario: Scenario B
+        Given a step which passes
+
+      Scenario: Scenario C
+        Given a step to write
+    """
+    And the file "./features/steps/junit_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a step which fails`, func() {
+            panic("oops")
+        })
+        reg.Step(`a step which passes`, func() {})
+        reg.Step(`a step to write`, Pending)
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin run -format=junit"
+    Then the output should contain
+    """
+    <testsuite name="JUnit Feature" tests="3" failures="1" errors="0" skipped="1"
+    """
+    And the output should contain
+    """
+    <failure message="../junit.feature:4: Given a step which fails: panic: oops" type="failed">
+    """
+    And the output should contain
+    """
+    <system-out>Given a step which fails ... failed&#xA;And a step which passes ... skipped&#xA;</system-out>
+    """
+    And the output should contain
+    """
+    <skipped message="pending"></skipped>
+    """
+
+  Scenario: A user reports a run in which every step passes.
+    Given the file "./features/passing.feature" exists with content
+    """
+    Feature: Passing Feature
+
+      Scenario: Scenario A
+        Given a step which passes
+    """
+    And the file "./features/steps/passing_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a step which passes`, func() {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin run -format=pretty -format=junit:report.xml"
+    Then the command should succeed
+    And the output should contain
+    """
+      Scenario: Scenario A        # ../passing.feature:3
+        Given a step which passes # passing_test.go:13
+    """
+    And the output should contain
+    """
+    1 scenario (1 passed), 1 step (1 passed)
+    """
+    And the file "report.xml" should contain
+    """
+    <testsuite name="Passing Feature" tests="1" failures="0" errors="0" skipped="0"
+    """
+
+  Scenario: A user reports a run as Cucumber JSON.
+    Given the file "./features/cucumber.feature" exists with content
+    """
+    @web
+    Feature: Cucumber Feature
+
+      @smoke
+      Scenario: Scenario A
+        Given a step which attaches a note
+        And a step which fails
+    """
+    And the file "./features/steps/cucumber_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a step which attaches a note`, func(s *StepInfo) {
+            s.Attach("text/plain", []byte("hello"))
+        })
+        reg.Step(`a step which fails`, func() {
+            panic("oops")
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin run -format=cucumber"
+    Then the output should contain
+    """
+        "uri": "../cucumber.feature",
+        "id": "cucumber-feature",
+        "keyword": "Feature",
+        "name": "Cucumber Feature",
+        "description": "",
+        "line": 2,
+        "tags": [
+          {
+            "name": "@web",
+            "line": 1
+          }
+        ],
+    """
+    And the output should contain
+    """
+            "id": "cucumber-feature;scenario-a",
+            "keyword": "Scenario",
+            "name": "Scenario A",
+            "description": "",
+            "line": 5,
+            "type": "scenario",
+    """
+    And the output should contain
+    """
+                "keyword": "Given ",
+                "name": "a step which attaches a note",
+                "line": 6,
+                "match": {
+                  "location": "cucumber_test.go:13"
+                },
+    """
+    And the output should contain
+    """
+                "embeddings": [
+                  {
+                    "mime_type": "text/plain",
+                    "data": "aGVsbG8="
+                  }
+                ]
+    """
+    And the output should contain
+    """
+                  "status": "failed",
+    """
+    And the output should contain
+    """
+                  "error_message": "../cucumber.feature:7: And a step which fails: panic: oops\n
+    """
@@ -11,6 +11,16 @@ Feature: Gorkin help.
       -help=false: Get usage on gorkin.
       -init=false: Initialize a Gherkin structure.
 
+    Commands:
+      run             Run the features against their steps. This is the default.
+        -dry-run      Match every step and check its arguments without running it.
+        -format       Report with a formatter, e.g. text or pretty:out.txt. May be repeated.
+        -no-color     Don't colour the output of the pretty formatter.
+        -color        Colour the output of the pretty formatter even when it isn't a terminal.
+      steps check     Report steps whose patterns can match the same text.
+      steps snippets  Print snippets for steps which have no definition.
+      steps unused    Run the features and fail if any step matched no line.
+
     """
 
     Scenario: A user runs "gorkin --init"
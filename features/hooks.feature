@@ -0,0 +1,262 @@
+Feature: Hooks
+  As a gorkin user
+  I would like to run code before and after suites, features, scenarios and steps
+  So that I don't have to set up and tear down state in every step.
+
+  Background:
+    Given the path "./features" exists
+    And the path "./features/steps" exists
+
+  Scenario: A user registers hooks around scenarios which fail.
+    Given the file "./features/hooks.feature" exists with content
+    """
+    Feature: Hooks Feature
+
+      Scenario: A
+        Given it panics
+
+      Scenario: B
+        Given it passes
+    """
+    And the file "./features/steps/hooks_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "strings"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        var events []string
+        reg := NewRegistry()
+        reg.BeforeSuite(func() {
+            events = append(events, "before suite")
+        })
+        reg.AfterSuite(func() {
+            t.Log("events: " + strings.Join(events, ", "))
+        })
+        reg.BeforeScenario(func(i *I, s *ScenarioInfo) {
+            events = append(events, "before "+s.Name)
+        })
+        reg.AfterScenario(func(s *ScenarioInfo) {
+            events = append(events, "after "+s.Name+" "+s.Status.String())
+        })
+        reg.AfterScenario(func() {
+            events = append(events, "after second")
+        })
+        reg.AfterStep(func(s *StepInfo) {
+            events = append(events, s.Text+" "+s.Status.String())
+        })
+        reg.Step(`it panics`, func() {
+            panic("boom")
+        })
+        reg.Step(`it passes`, func() {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    events: before suite, before A, Given it panics failed, after second, after A failed, before B, Given it passes passed, after second, after B passed
+    """
+    And the output should contain
+    """
+    panic: boom
+    """
+
+  Scenario: A user registers hooks for scenarios with certain tags.
+    Given the file "./features/tags.feature" exists with content
+    """
+    @db
+    Feature: Tags Feature
+
+      Scenario: Writes
+        Given it runs
+
+      @readonly @fast
+      Scenario: Reads
+        Given it runs
+
+      @http
+      Scenario: Serves
+        Given it runs
+    """
+    And the file "./features/steps/tags_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "strings"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        var events []string
+        reg := NewRegistry()
+        reg.AfterSuite(func() {
+            t.Error("events: " + strings.Join(events, ", "))
+        })
+        reg.Before("@db and not @readonly", func(s *ScenarioInfo) {
+            events = append(events, "database for "+s.Name)
+        })
+        reg.After("@http or (@fast and not @db)", func(s *ScenarioInfo) {
+            events = append(events, "server for "+s.Name)
+        })
+        reg.Step(`it runs`, func() {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    events: database for Writes, database for Serves, server for Serves
+    """
+
+  Scenario: A user's isolation type sets up and tears down each scenario.
+    Given the file "./features/isolation.feature" exists with content
+    """
+    Feature: Isolation Feature
+
+      Scenario: A
+        Given it runs
+
+      Scenario: B
+        Given it runs
+    """
+    And the file "./features/steps/isolation_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "strings"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    var events []string
+
+    type I struct {
+        name string
+    }
+
+    func (i *I) Setup(t *testing.T) error {
+        i.name = t.Name()[strings.LastIndex(t.Name(), "/")+1:]
+        events = append(events, "setup "+i.name)
+        return nil
+    }
+
+    func (i *I) Teardown(t *testing.T) error {
+        events = append(events, "teardown "+i.name)
+        return nil
+    }
+
+    func (i *I) Close() error {
+        events = append(events, "close "+i.name)
+        return nil
+    }
+
+    func Test(t *testing.T) {
+        reg := NewRegistry()
+        reg.AfterSuite(func() {
+            t.Error("events: " + strings.Join(events, ", "))
+        })
+        reg.AfterScenario(func(i *I) {
+            events = append(events, "after "+i.name)
+        })
+        reg.Step(`it runs`, func(i *I) {
+            events = append(events, "step "+i.name)
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    events: setup A, step A, after A, teardown A, close A, setup B, step B, after B, teardown B, close B
+    """
+
+  Scenario: A user provides more than one kind of state to their steps.
+    Given the file "./features/worlds.feature" exists with content
+    """
+    Feature: Worlds Feature
+
+      Scenario: A
+        Given a client
+        And a client and a database
+
+      Scenario: B
+        Given nothing
+    """
+    And the file "./features/steps/worlds_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "strings"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    var events []string
+
+    type DBState struct{}
+
+    func (db *DBState) Close() error {
+        events = append(events, "close database")
+        return nil
+    }
+
+    type APIClient struct {
+        db *DBState
+    }
+
+    func (c *APIClient) Teardown(t *testing.T) error {
+        events = append(events, "tear down client")
+        return nil
+    }
+
+    func Test(t *testing.T) {
+        type I struct{}
+
+        reg := NewRegistry()
+        reg.AfterSuite(func() {
+            t.Error("events: " + strings.Join(events, ", "))
+        })
+        reg.Provide(func() *DBState {
+            events = append(events, "new database")
+            return &DBState{}
+        })
+        reg.Provide(func(db *DBState) (*APIClient, error) {
+            events = append(events, "new client")
+            return &APIClient{db: db}, nil
+        })
+        reg.Step(`a client`, func(c *APIClient) {
+            events = append(events, "client step")
+        })
+        reg.Step(`a client and a database`, func(c *APIClient, db *DBState) {
+            if c.db == db {
+                events = append(events, "shared database")
+            }
+        })
+        reg.Step(`nothing`, func() {
+            events = append(events, "nothing step")
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    events: new database, new client, client step, shared database, tear down client, close database, nothing step
+    """
@@ -0,0 +1,398 @@
+Feature: Converting Step Arguments
+  As a gorkin user
+  I would like the text matched by my steps converted to the types my steps accept
+  So that my steps don't have to parse their own arguments.
+
+  Background:
+    Given the path "./features" exists
+    And the path "./features/steps" exists
+
+  Scenario: A user writes steps which accept many types.
+    Given the file "./features/types.feature" exists with content
+    """
+    Feature: Types Feature
+
+      Scenario: Scenario A
+        Given a level of 7, a count of 42 and a ratio of 0.5
+        And a timeout of 1m30s starting at 2016-01-02T15:04:05Z
+        And a host of 127.0.0.1 named "web" with key "abc"
+        And a limit
+    """
+    And the file "./features/steps/types_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "net"
+        "testing"
+        "time"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type Name string
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a level of (\d+), a count of (\d+) and a ratio of ([\d.]+)`, func(level int8, count uint64, ratio float32) {
+            if level != 7 || count != 42 || ratio != 0.5 {
+                t.Fatalf("unexpected numbers: %v %v %v", level, count, ratio)
+            }
+        })
+        reg.Step(`a timeout of (\S+) starting at (\S+)`, func(timeout time.Duration, start time.Time) {
+            if timeout != 90*time.Second || start.Year() != 2016 {
+                t.Fatalf("unexpected times: %v %v", timeout, start)
+            }
+        })
+        reg.Step(`a host of (\S+) named "(\w+)" with key "(\w+)"`, func(ip net.IP, name Name, key []byte) {
+            if !ip.IsLoopback() || name != "web" || string(key) != "abc" {
+                t.Fatalf("unexpected host: %v %v %s", ip, name, key)
+            }
+        })
+        reg.Step(`a limit(?: of (\d+))?`, func(limit *int) {
+            if limit != nil {
+                t.Fatalf("unexpected limit: %d", *limit)
+            }
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user runs a step whose argument cannot be converted.
+    Given the file "./features/bad-argument.feature" exists with content
+    """
+    Feature: Bad Argument Feature
+
+      Scenario: Scenario A
+        Given a level of 300
+        Then nothing else should run
+
+      Scenario: Scenario B
+        Given a level of 3
+
+      Scenario: Scenario C
+        Given a small count of 300
+
+      Scenario: Scenario D
+        Given a count of -1
+
+      Scenario: Scenario E
+        Given a level which can't be found
+    """
+    And the file "./features/steps/bad_argument_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "errors"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a level of (\d+)`, func(level int8) {
+            t.Log("ran level", level)
+        })
+        reg.Step(`nothing else should run`, func() {
+            t.Fatal("the scenario continued after a failed step")
+        })
+        reg.Step(`a small count of {int}`, func(n uint8) {
+            t.Log("ran small count", n)
+        })
+        reg.Step(`a count of {int}`, func(n uint) {
+            t.Log("ran count", n)
+        })
+        reg.Step(`a level which can't be found`, func() error {
+            return errors.New("no such level")
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    bad-argument.feature:4: Given a level of 300: group 1 ("300"): cannot convert "300" to int8: value out of range
+    """
+    And the output should contain
+    """
+    ran level 3
+    """
+    And the output should contain
+    """
+    bad-argument.feature:11: Given a small count of 300: group 1 ("300"): cannot convert "300" to uint8: value out of range
+    """
+    And the output should contain
+    """
+    bad-argument.feature:14: Given a count of -1: group 1 ("-1"): cannot convert "-1" to uint: value out of range
+    """
+    And the output should contain
+    """
+    bad-argument.feature:17: Given a level which can't be found: no such level
+    """
+
+  Scenario: A user writes steps which accept booleans.
+    Given the file "./features/flags.feature" exists with content
+    """
+    # language: de
+    Feature: Flags Feature
+
+      Scenario: Scenario A
+        Given the flag is "false"
+        And the switch is "ja"
+        And the path "foo" doesn't exist
+        And the path "bar" exists
+    """
+    And the file "./features/steps/flags_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`the (?:flag|switch) is "(\w+)"`, func(t *testing.T, on bool) {
+            t.Log("flag is", on)
+        })
+        reg.Step(`the path "(\w+)"( doesn't)? exists?`, func(t *testing.T, path string, missing Present) {
+            t.Log(path, "is missing:", missing)
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "go test -v ./features/steps/..."
+    Then the output should contain
+    """
+    flag is false
+    """
+    And the output should contain
+    """
+    flag is true
+    """
+    And the output should contain
+    """
+    foo is missing: true
+    """
+    And the output should contain
+    """
+    bar is missing: false
+    """
+
+  Scenario: A user binds named groups to the fields of a struct.
+    Given the file "./features/order.feature" exists with content
+    """
+    Feature: Order Feature
+
+      Scenario: Scenario A
+        Given an order of 3 "apples" for "bob" at 1.25 each, express
+    """
+    And the file "./features/steps/order_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type Order struct {
+        Count    int
+        Item     string
+        Customer string  `gorkin:"who"`
+        Price    float64
+        Express  Present
+    }
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(
+            `an order of (?P<count>\d+) "(?P<item>\w+)" for "(?P<who>\w+)" at (?P<price>[\d.]+) each(?P<express>, express)?`,
+            func(t *testing.T, i *I, o Order) {
+                if o != (Order{3, "apples", "bob", 1.25, true}) {
+                    t.Fatalf("unexpected order: %+v", o)
+                }
+            },
+        )
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user decodes doc strings according to their media type.
+    Given the file "./features/requests.feature" exists with content
+    """
+    Feature: Requests Feature
+
+      Scenario: Scenario A
+        Given the request
+          ```json
+          {"name": "bob", "roles": ["admin"]}
+          ```
+        And the request as XML
+          ```xml
+          <Request>
+            <Name>bob</Name>
+            <Roles>admin</Roles>
+          </Request>
+          ```
+        And the settings
+          ```yaml
+          timeout: 1m
+          verbose: yes
+          ```
+        And the users
+          ```csv
+          name, age
+          alice, 30
+          bob, 40
+          ```
+
+      Scenario: Scenario B
+        Given the request
+          ```json
+          {
+            "name": 3
+          }
+          ```
+    """
+    And the file "./features/steps/requests_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+        "time"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type Request struct {
+        Name  string
+        Roles []string
+    }
+
+    type Settings struct {
+        Timeout time.Duration
+        Verbose bool
+    }
+
+    type User struct {
+        Name string
+        Age  int
+    }
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`the request`, func(req Request) {
+            if req.Name != "bob" || len(req.Roles) != 1 {
+                t.Fatalf("unexpected request: %+v", req)
+            }
+        })
+        reg.Step(`the request as XML`, func(req *Request) {
+            if req.Name != "bob" || len(req.Roles) != 1 {
+                t.Fatalf("unexpected request: %+v", req)
+            }
+        })
+        reg.Step(`the settings`, func(s *Settings) {
+            if s.Timeout != time.Minute || !s.Verbose {
+                t.Fatalf("unexpected settings: %+v", s)
+            }
+        })
+        reg.Step(`the users`, func(users []*User) {
+            if len(users) != 2 || users[1].Age != 40 {
+                t.Fatalf("unexpected users: %+v", users)
+            }
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin run -format=pretty"
+    Then the output should contain
+    """
+    2 scenarios (1 passed, 1 failed), 5 steps (4 passed, 1 failed)
+    """
+    And the output should contain
+    """
+    requests.feature:31: cannot decode json doc string into steps.Request: json: cannot unmarshal number into Go struct field Request.name of type string
+    """
+
+  Scenario: A user writes steps with variadic and optional parameters.
+    Given the file "./features/roles.feature" exists with content
+    """
+    Feature: Roles Feature
+
+      Scenario: Scenario A
+        Given the user has roles "a", "b, c" and "d"
+        And the user has scores 1, 2, 3
+        And the user has 2 of 3 badges
+        And the user has badges
+        And the user has nicknames
+    """
+    And the file "./features/steps/roles_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "reflect"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`the user has roles (.+)`, func(roles ...string) {
+            if !reflect.DeepEqual(roles, []string{"a", "b, c", "d"}) {
+                t.Fatalf("unexpected roles: %q", roles)
+            }
+        })
+        reg.Step(`the user has scores (.+)`, func(t *testing.T, scores ...int) {
+            if !reflect.DeepEqual(scores, []int{1, 2, 3}) {
+                t.Fatalf("unexpected scores: %v", scores)
+            }
+        })
+        reg.Step(`the user has (?:(\d+) of (\d+) )?badges`, func(earned, total int) {
+            if earned > total {
+                t.Fatalf("unexpected badges: %d of %d", earned, total)
+            }
+        })
+        reg.Step(`the user has nicknames`, func(name string, nicknames ...string) {
+            if len(nicknames) != 0 {
+                t.Fatalf("unexpected nicknames: %q", nicknames)
+            }
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    ok
+    """
@@ -0,0 +1,439 @@
+Feature: Matching Steps
+  As a gorkin user
+  I would like to know exactly which step will run for each line of a feature
+  So that my tests behave the same way every time they are run.
+
+  Background:
+    Given the path "./features" exists
+    And the path "./features/steps" exists
+
+  Scenario: A user runs a feature with a line matching more than one step.
+    Given the file "./features/ambiguous.feature" exists with content
+    """
+    Feature: Ambiguous Feature
+
+      Scenario: Scenario A
+        Given the state is "1"
+    """
+    And the file "./features/steps/ambiguous_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`the state is "([^"]+)"`, func(state string) {})
+        reg.Step(`the state is "(\d+)"`, func(state int) {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    Ambiguous step matches 2 runners:
+    """
+    And the output should contain
+    """
+    1 steps match more than one runner. Make their patterns more specific or set Options.MatchPolicy; gorkin steps check lists every overlap.
+    """
+
+  Scenario: A user prefers the most specific step when more than one matches.
+    Given the file "./features/specific.feature" exists with content
+    """
+    Feature: Specific Feature
+
+      Scenario: Scenario A
+        Given the state is "1"
+    """
+    And the file "./features/steps/specific_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Options.MatchPolicy = MatchMostSpecific
+        reg.Step(`the (\w+) is "([^"]+)"`, func() {
+            t.Fatal("the less specific step was run")
+        })
+        reg.Step(`the state is "([^"]+)"`, func(state string) {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user checks their steps for patterns which overlap.
+    Given the file "./features/overlap.feature" exists with content
+    """
+    Feature: Overlap Feature
+
+      Scenario: Scenario A
+        Given the state is "1"
+    """
+    And the file "./features/steps/overlap_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Options.MatchPolicy = MatchFirstRegistered
+        reg.Step(`the state is "([^"]+)"`, func(state string) {})
+        reg.Step(`the state is "(\d+)"`, func(state int) {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin steps check"
+    Then the output should contain
+    """
+    both match "the state is \"0\"".
+    """
+
+  Scenario: A user runs a feature whose steps only partially match a pattern.
+    Given the file "./features/partial.feature" exists with content
+    """
+    Feature: Partial Feature
+
+      Scenario: Scenario A
+        Given ivy grows
+        Then the state should be "1"
+    """
+    And the file "./features/steps/partial_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`ivy grows`, func() {})
+        reg.Step(`state should be "([^"]+)"`, func(state string) {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    Then the state should be "1"  ✗ No matching runner.
+    """
+    Given the file "./features/steps/partial_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Options.Unanchored = true
+        reg.Step(`ivy grows`, func() {})
+        reg.Step(`state should be "([^"]+)"`, func(state string) {
+            if state != "1" {
+                t.Fatalf("unexpected state: %s", state)
+            }
+        })
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the command should succeed
+    And the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user writes steps as Cucumber Expressions.
+    Given the file "./features/expressions.feature" exists with content
+    """
+    Feature: Expressions Feature
+
+      Scenario: Scenario A
+        Given an account named "bob" with 3 friends
+        And a balance of -1.5
+        Then they should see 1 example
+        And they should see 12345678901234567890 examples
+        And they have an apple
+        And they have a pear
+    """
+    And the file "./features/steps/expressions_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "math/big"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a/an account named {string} with {int} friend(s)`, func(name string, friends int) {
+            if name != "bob" || friends != 3 {
+                t.Fatalf("unexpected account: %s with %d friends", name, friends)
+            }
+        })
+        reg.Step(`a balance of {float}`, func(balance float64) {
+            if balance != -1.5 {
+                t.Fatalf("unexpected balance: %v", balance)
+            }
+        })
+        reg.Step(`they should see {bigint} example(s)`, func(n *big.Int) {
+            if n.Sign() <= 0 {
+                t.Fatalf("unexpected number of examples: %v", n)
+            }
+        })
+        reg.StepExpression(`they have a/an apple/pear`, func() {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    ok
+    """
+
+  Scenario: A user registers their own parameter type.
+    Given the file "./features/colors.feature" exists with content
+    """
+    Feature: Colors Feature
+
+      Scenario: Scenario A
+        Given the sky is blue
+        Then the grass is "green"
+        And the sea is "blurple"
+    """
+    And the file "./features/steps/colors_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "fmt"
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type Color string
+
+    func parseColor(s string) (Color, error) {
+        switch s {
+        case "red", "green", "blue":
+            return Color(s), nil
+        }
+        return "", fmt.Errorf("unknown color")
+    }
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.ParameterType("color", `\w+`, parseColor)
+        reg.Step(`the sky is {color}`, func(c Color) {
+            if c != "blue" {
+                t.Fatalf("unexpected color: %s", c)
+            }
+        })
+        reg.Step(`the (?:grass|sea) is "(\w+)"`, func(c Color) {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    colors.feature:6: And the sea is "blurple": group 1 ("blurple"): could not convert "blurple" to {color}: unknown color
+    """
+
+  Scenario: A user is offered snippets for steps which have no definition.
+    Given the file "./features/undefined.feature" exists with content
+    """
+    Feature: Undefined Feature
+
+      Scenario: Scenario A
+        Given an account named "bob" with 3 friends
+        When the balance (in euros) is -1.5
+        Then bob should see the message
+        ```
+        Hello!
+        ```
+        And an account named "alice" with 4 friends
+    """
+    And the file "./features/steps/undefined_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    type World struct {}
+
+    func Test(t *testing.T) {
+        reg := NewRegistry()
+        reg.RunFeatureTests(t, &World{})
+    }
+    """
+    When a user runs "gorkin steps snippets"
+    Then the output should contain
+    """
+    You can implement the missing steps with these snippets:
+    """
+    And the output should contain
+    """
+    Step(`an account named {string} with {int} friends`, func(w *World, arg1 string, arg2 int) {
+    """
+    And the output should contain
+    """
+    Step(`the balance \(in euros\) is {float}`, func(w *World, arg1 float64) {
+    """
+    And the output should contain
+    """
+    Step(`bob should see the message`, func(w *World, doc string) {
+    """
+    And the output should contain
+    """
+    	// Write the code which makes this step pass here.
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    Please implement the missing runners.
+    """
+
+  Scenario: A user is told which steps they may have meant.
+    Given the file "./features/typos.feature" exists with content
+    """
+    Feature: Typos Feature
+
+      Scenario: Scenario A
+        Given a usr logs in
+        Then they should see 3 example
+        And they should see nothing at all
+    """
+    And the file "./features/steps/typos_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a user logs in`, func() {})
+        reg.Step(`they should see {int} examples`, func(n int) {})
+        reg.Step(`a user logs out`, func() {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin"
+    Then the output should contain
+    """
+    ../typos.feature:4: Given a usr logs in
+            	Did you mean:
+            		`a user logs in` (typos_test.go:13)
+            		`a user logs out` (typos_test.go:15)
+    """
+    And the output should contain
+    """
+    ../typos.feature:5: Then they should see 3 example
+            	Did you mean:
+            		`they should see {int} examples` (typos_test.go:14)
+    """
+
+  Scenario: A user finds the steps which no feature uses.
+    Given the file "./features/unused.feature" exists with content
+    """
+    Feature: Unused Feature
+
+      Scenario: Scenario A
+        Given a user logs in
+    """
+    And the file "./features/ambiguous.feature" exists with content
+    """
+    Feature: Ambiguous Feature
+
+      Scenario: Scenario A
+        Given a user logs in as admin
+    """
+    And the file "./features/steps/unused_test.go" exists with content
+    """
+    package steps
+
+    import (
+        "testing"
+
+        . "github.com/kat-co/gorkin/gorkin"
+    )
+
+    func Test(t *testing.T) {
+        type I struct {}
+
+        reg := NewRegistry()
+        reg.Step(`a user logs in`, func() {})
+        reg.Step(`a user logs out`, func() {})
+        reg.Step(`a user signs up`, func() {})
+        reg.Step(`a user logs in as {word}`, func(role string) {})
+        reg.Step(`a user logs in as (admin|guest)`, func(role string) {})
+        reg.RunFeatureTests(t, &I{})
+    }
+    """
+    When a user runs "gorkin steps unused"
+    Then the command should fail
+    And the output should contain
+    """
+    2 steps matched no line of any feature:
+            	`a user logs out` (unused_test.go:14)
+            	`a user signs up` (unused_test.go:15)
+    """
+    When a user runs "gorkin run -dry-run"
+    Then the output should contain
+    """
+    2 steps matched no line of any feature:
+            	`a user logs out` (unused_test.go:14)
+            	`a user signs up` (unused_test.go:15)
+    """
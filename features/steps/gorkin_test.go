@@ -14,14 +14,27 @@ import (
 
 func TestFeatures(t *testing.T) {
 
+	// Steps are kept in their own registry so that running this test
+	// more than once doesn't redefine them.
+	reg := NewRegistry()
+
 	// Isolation layer.
 	type I struct {
 		dir         string
 		featureFile string
 		gorkResult  string
+		failed      bool
 	}
 
-	Step(`the path \"([^"]+)\"( doesn't)? exists?`, func(f *I, dirName string, deleteIfExists bool) {
+	reg.AfterScenario(func(f *I) {
+		if f.dir != "" {
+			os.RemoveAll(f.dir)
+		}
+	})
+
+	// Steps accept the scenario's *testing.T so that a failing step
+	// only fails its own scenario.
+	reg.Step(`the path \"([^"]+)\"( doesn't)? exists?`, func(t *testing.T, f *I, dirName string, deleteIfExists Present) {
 
 		// Create a temporary directory to operate within.
 		if f.dir == "" {
@@ -41,7 +54,7 @@ func TestFeatures(t *testing.T) {
 		}
 	})
 
-	Step(`the file \"([^"]+)\" exists(?: with content)?`, func(f *I, filePath, content string) {
+	reg.Step(`the file {string} exists( with content)`, func(t *testing.T, f *I, filePath, content string) {
 
 		file := filepath.Join(f.dir, filePath)
 		if err := ioutil.WriteFile(file, []byte(content), 0666); err != nil {
@@ -49,13 +62,13 @@ func TestFeatures(t *testing.T) {
 		}
 	})
 
-	Step(`there is a steps directory under features`, func(f *I) {
+	reg.Step(`there is a steps directory under features`, func(t *testing.T, f *I) {
 		if err := os.Mkdir(filepath.Join(f.dir, "features", "steps"), 0777); err != nil {
 			t.Fatalf("could not create feature file: %v", err)
 		}
 	})
 
-	Step(`a user runs \"([^"]+)\"`, func(f *I, command string) {
+	reg.Step(`a user runs {string}`, func(t *testing.T, f *I, command string) {
 
 		if f.dir != "" {
 			cwd, err := os.Getwd()
@@ -73,32 +86,57 @@ func TestFeatures(t *testing.T) {
 		cmdAndArgs := strings.Split(command, " ")
 		cmd := exec.Command(cmdAndArgs[0], cmdAndArgs[1:]...)
 		output, err := cmd.CombinedOutput()
-		if err != nil {
+		_, f.failed = err.(*exec.ExitError)
+		if err != nil && !f.failed {
 			t.Fatalf("could not run gorkin: %v", err)
 		}
 
 		f.gorkResult = string(output)
 	})
 
-	Step(`gorkin should find the features directory`, func(f *I) {
+	reg.Step(`the command should (fail|succeed)`, func(t *testing.T, f *I, outcome string) {
+		if f.failed != (outcome == "fail") {
+			t.Fatalf(`expected the command to %s: "%v"`, outcome, f.gorkResult)
+		}
+	})
+
+	reg.Step(`gorkin should find the features directory`, func(t *testing.T, f *I) {
 		if strings.Contains(f.gorkResult, "Processing:") == false {
 			t.Fatalf("gorkin did not find the features directory: %s", f.gorkResult)
 		}
 	})
 
-	Step(`the output should be`, func(f *I, output string) {
+	reg.Step(`the output should be`, func(t *testing.T, f *I, output string) {
 		if f.gorkResult != output {
 			t.Errorf(`Expected output: "%s"`, output)
 			t.Fatalf(`unexpected result from gorkin: "%v"`, f.gorkResult)
 		}
 	})
 
-	Step(`the output should contain`, func(f *I, output string) {
+	reg.Step(`the output should contain`, func(t *testing.T, f *I, output string) {
 		if !strings.Contains(f.gorkResult, output) {
 			t.Logf(`Expected output: "%s"`, output)
 			t.Fatalf(`unexpected result from gorkin: "%v"`, f.gorkResult)
 		}
 	})
 
-	RunFeatureTests(t, &I{})
+	reg.Step(`the output should contain exactly once`, func(t *testing.T, f *I, output string) {
+		if n := strings.Count(f.gorkResult, output); n != 1 {
+			t.Logf(`Expected output once: "%s"`, output)
+			t.Fatalf(`found %d times in the result from gorkin: "%v"`, n, f.gorkResult)
+		}
+	})
+
+	reg.Step(`the file {string} should contain`, func(t *testing.T, f *I, filePath, content string) {
+		written, err := ioutil.ReadFile(filepath.Join(f.dir, filePath))
+		if err != nil {
+			t.Fatalf("could not read file: %v", err)
+		}
+		if !strings.Contains(string(written), content) {
+			t.Logf(`Expected content: "%s"`, content)
+			t.Fatalf(`unexpected content in %s: "%s"`, filePath, written)
+		}
+	})
+
+	reg.RunFeatureTests(t, &I{})
 }
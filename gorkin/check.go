@@ -0,0 +1,240 @@
+package gorkin
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+	"unicode"
+)
+
+// StepConflict describes two registered steps whose patterns can both
+// match the same line of a feature.
+type StepConflict struct {
+	// First is the pattern of the step which was registered first.
+	First string
+	// FirstLocation is where the first step's function is defined.
+	FirstLocation string
+	// Second is the pattern of the step which was registered second.
+	Second string
+	// SecondLocation is where the second step's function is defined.
+	SecondLocation string
+	// Example is a line which both patterns match.
+	Example string
+}
+
+func (c *StepConflict) Error() string {
+	return fmt.Sprintf("Steps `%s` (%s) and `%s` (%s) both match %q.",
+		c.First, c.FirstLocation,
+		c.Second, c.SecondLocation,
+		c.Example,
+	)
+}
+
+// CheckRegistry reports every pair of steps registered with
+// DefaultRegistry whose patterns can match the same text.
+func CheckRegistry() []*StepConflict {
+	return DefaultRegistry.Check()
+}
+
+// Check reports every pair of steps registered with r whose patterns
+// can match the same text.
+//
+// Patterns are compared by generating example text from each pattern
+// and matching it against the others, so overlaps which only occur for
+// unusual text may go unreported. Every conflict which is reported is
+// genuine, and comes with an example of a line both steps match.
+func (r *Registry) Check() []*StepConflict {
+	examples := make([][]string, len(r.steps))
+	for i, def := range r.steps {
+		re, err := syntax.Parse(def.matcher(r.Options).String(), syntax.Perl)
+		if err != nil {
+			// Step will have already panicked on a bad regex.
+			continue
+		}
+		examples[i] = generateExamples(re.Simplify())
+	}
+
+	var conflicts []*StepConflict
+	for i, a := range r.steps {
+		for j := i + 1; j < len(r.steps); j++ {
+			b := r.steps[j]
+			aRegex, bRegex := a.matcher(r.Options), b.matcher(r.Options)
+			if example, ok := sharedExample(aRegex, bRegex, examples[i], examples[j]); ok {
+				conflicts = append(conflicts, &StepConflict{
+					First:          a.Pattern,
+					FirstLocation:  a.Location(),
+					Second:         b.Pattern,
+					SecondLocation: b.Location(),
+					Example:        example,
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// sharedExample looks for an example of either regex which both
+// regexes match.
+func sharedExample(a, b *regexp.Regexp, aExamples, bExamples []string) (string, bool) {
+	for _, examples := range [][]string{aExamples, bExamples} {
+		for _, example := range examples {
+			if a.MatchString(example) && b.MatchString(example) {
+				return example, true
+			}
+		}
+	}
+	return "", false
+}
+
+// maxExamples bounds the number of examples generated for any part of
+// a pattern so that long patterns don't explode combinatorially.
+const maxExamples = 64
+
+// probeRunes are tried, in order, as representatives of character
+// classes. They're chosen to be the characters most likely to appear
+// in the text of a step.
+var probeRunes = []rune(`a0 A_-."'`)
+
+// generateExamples returns a sample of text matched by re.
+func generateExamples(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return []string{string(re.Rune), string(foldRunes(re.Rune))}
+		}
+		return []string{string(re.Rune)}
+	case syntax.OpCharClass:
+		return classExamples(re.Rune)
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		return []string{"a", "0", " "}
+	case syntax.OpCapture:
+		return generateExamples(re.Sub[0])
+	case syntax.OpStar:
+		return repeatExamples(generateExamples(re.Sub[0]), 0, 2)
+	case syntax.OpPlus:
+		return repeatExamples(generateExamples(re.Sub[0]), 1, 2)
+	case syntax.OpQuest:
+		return repeatExamples(generateExamples(re.Sub[0]), 0, 1)
+	case syntax.OpRepeat:
+		max := re.Max
+		if max < 0 || max > re.Min+1 {
+			max = re.Min + 1
+		}
+		return repeatExamples(generateExamples(re.Sub[0]), re.Min, max)
+	case syntax.OpConcat:
+		examples := []string{""}
+		for _, sub := range re.Sub {
+			examples = concatExamples(examples, generateExamples(sub))
+		}
+		return examples
+	case syntax.OpAlternate:
+		var examples []string
+		for _, sub := range re.Sub {
+			examples = appendExamples(examples, generateExamples(sub)...)
+		}
+		return examples
+	case syntax.OpNoMatch:
+		return nil
+	}
+	// Everything else matches the empty string.
+	return []string{""}
+}
+
+func classExamples(ranges []rune) []string {
+	var examples []string
+	contains := func(r rune) bool {
+		for i := 0; i < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range probeRunes {
+		if contains(r) {
+			examples = append(examples, string(r))
+		}
+	}
+	if len(examples) == 0 && len(ranges) > 0 {
+		examples = append(examples, string(ranges[0]))
+	}
+	return examples
+}
+
+func repeatExamples(examples []string, min, max int) []string {
+	var repeated []string
+	for n := min; n <= max; n++ {
+		current := []string{""}
+		for i := 0; i < n; i++ {
+			current = concatExamples(current, examples)
+		}
+		repeated = appendExamples(repeated, current...)
+	}
+	return repeated
+}
+
+func concatExamples(heads, tails []string) []string {
+	var examples []string
+	for _, head := range heads {
+		for _, tail := range tails {
+			if examples = appendExamples(examples, head+tail); len(examples) >= maxExamples {
+				return examples
+			}
+		}
+	}
+	return examples
+}
+
+func appendExamples(examples []string, more ...string) []string {
+	for _, example := range more {
+		if len(examples) >= maxExamples {
+			break
+		}
+		examples = append(examples, example)
+	}
+	return examples
+}
+
+func foldRunes(runes []rune) []rune {
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = unicode.SimpleFold(r)
+	}
+	return folded
+}
+
+// unusedSteps returns the registered steps whose patterns haven't
+// matched a line of any feature, or a step run with RunStep, since
+// RunFeatureTests was called.
+func (r *Registry) unusedSteps() []*stepDefinition {
+	var unused []*stepDefinition
+	for _, def := range r.steps {
+		if !r.used[def.Pattern] {
+			unused = append(unused, def)
+		}
+	}
+	return unused
+}
+
+// reportUnusedSteps logs the steps which were never used, failing t
+// with them instead if -gorkin.fail-unused is set.
+func (r *Registry) reportUnusedSteps(t *testing.T) {
+	unused := r.unusedSteps()
+	if len(unused) == 0 {
+		return
+	}
+
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "%d steps matched no line of any feature:", len(unused))
+	for _, def := range unused {
+		fmt.Fprintf(msg, "\n\t`%s` (%s)", def.Pattern, def.Location())
+	}
+
+	if *failUnused {
+		t.Error(msg)
+	} else {
+		t.Log(msg)
+	}
+}
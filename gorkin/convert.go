@@ -0,0 +1,410 @@
+package gorkin
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	presentType  = reflect.TypeOf(Present(false))
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Present can be accepted by a step in place of an optional group. It
+// is true when the group took part in the match, regardless of what
+// it captured. e.g. for the regex `the path "(\w+)"( doesn't)? exists?`
+// a step accepting (string, Present) is passed true for:
+//
+//	Given the path "foo" doesn't exist
+type Present bool
+
+// timeLayouts are tried, in order, when converting text to a
+// time.Time.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	"15:04:05",
+	"15:04",
+	time.Kitchen,
+}
+
+// convertArg converts text captured by a group into a value of type
+// to. matched is false when the group was optional and took no part
+// in the match, in which case to's zero value, e.g. nil for pointers,
+// is returned. language is the language of the feature the text came
+// from, and decides which words are accepted as booleans.
+//
+// Besides the basic kinds, any type whose pointer implements
+// encoding.TextUnmarshaler or flag.Value can be converted to, as can
+// time.Duration, time.Time, []byte and Present.
+func convertArg(text string, matched bool, language string, to reflect.Type) (reflect.Value, error) {
+
+	if to == presentType {
+		return reflect.ValueOf(Present(matched)), nil
+	}
+
+	if !matched {
+		return reflect.Zero(to), nil
+	}
+
+	if to.Kind() == reflect.Ptr {
+		elem, err := convertArg(text, matched, language, to.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(to.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	switch to {
+	case durationType:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %q to a duration", text)
+		}
+		return reflect.ValueOf(d), nil
+	case timeType:
+		for _, layout := range timeLayouts {
+			if tm, err := time.Parse(layout, text); err == nil {
+				return reflect.ValueOf(tm), nil
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("cannot convert %q to a time; try RFC 3339, e.g. %q", text, time.RFC3339)
+	}
+
+	ptr := reflect.New(to)
+	switch v := ptr.Interface().(type) {
+	case encoding.TextUnmarshaler:
+		if err := v.UnmarshalText([]byte(text)); err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %q to %v: %v", text, to, err)
+		}
+		return ptr.Elem(), nil
+	case flag.Value:
+		if err := v.Set(text); err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot convert %q to %v: %v", text, to, err)
+		}
+		return ptr.Elem(), nil
+	}
+
+	val := ptr.Elem()
+	switch to.Kind() {
+	default:
+		return reflect.Value{}, fmt.Errorf(
+			`Cannot handle steps which accept arguments of type "%v" at this time.`,
+			to,
+		)
+	case reflect.String:
+		val.SetString(text)
+	case reflect.Bool:
+		b, err := parseBool(text, language)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		val.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(text, 10, to.Bits())
+		if err != nil {
+			return reflect.Value{}, numError(text, to, err)
+		}
+		val.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(text, 10, to.Bits())
+		if err != nil {
+			return reflect.Value{}, numError(text, to, err)
+		}
+		val.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, to.Bits())
+		if err != nil {
+			return reflect.Value{}, numError(text, to, err)
+		}
+		val.SetFloat(f)
+	case reflect.Slice:
+		if to.Elem().Kind() != reflect.Uint8 {
+			return reflect.Value{}, fmt.Errorf(
+				`Cannot handle steps which accept arguments of type "%v" at this time.`,
+				to,
+			)
+		}
+		val.SetBytes([]byte(text))
+	}
+	return val, nil
+}
+
+// numError describes a failure to parse text as a number of type to
+// without repeating the text strconv already includes.
+func numError(text string, to reflect.Type, err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok {
+		err = numErr.Err
+	}
+	return fmt.Errorf("cannot convert %q to %v: %v", text, to, err)
+}
+
+// booleanWords maps a language to the words, in lower case, which
+// mean true or false in it. English words are understood in every
+// language.
+var booleanWords = map[string]map[string]bool{
+	"en": {
+		"true": true, "yes": true, "on": true, "enabled": true,
+		"false": false, "no": false, "off": false, "disabled": false,
+	},
+	"de": {
+		"wahr": true, "ja": true, "an": true, "aktiviert": true,
+		"falsch": false, "nein": false, "aus": false, "deaktiviert": false,
+	},
+	"es": {
+		"verdadero": true, "sí": true, "si": true, "activado": true,
+		"falso": false, "no": false, "desactivado": false,
+	},
+	"fr": {
+		"vrai": true, "oui": true, "activé": true,
+		"faux": false, "non": false, "désactivé": false,
+	},
+}
+
+// RegisterBooleanWords adds words which are understood as true or
+// false by steps accepting a bool in features written in language,
+// which is set with a "# language: xx" comment at the top of a
+// feature.
+func RegisterBooleanWords(language string, trues, falses []string) {
+	words, ok := booleanWords[language]
+	if !ok {
+		words = make(map[string]bool)
+		booleanWords[language] = words
+	}
+	for _, w := range trues {
+		words[strings.ToLower(w)] = true
+	}
+	for _, w := range falses {
+		words[strings.ToLower(w)] = false
+	}
+}
+
+// parseBool understands text as a boolean in language, or in English.
+func parseBool(text, language string) (bool, error) {
+	word := strings.ToLower(strings.TrimSpace(text))
+	for _, lang := range []string{language, "en"} {
+		if b, ok := booleanWords[lang][word]; ok {
+			return b, nil
+		}
+	}
+	if b, err := strconv.ParseBool(word); err == nil {
+		return b, nil
+	}
+	return false, fmt.Errorf(
+		"cannot convert %q to bool; use one of true/false, yes/no, on/off or enabled/disabled, or accept a gorkin.Present to test whether an optional group matched",
+		text,
+	)
+}
+
+// convertGroup converts the argument captured by the group at
+// groupIdx into a value of type to. Parameters from Cucumber
+// Expressions, and groups passed to a registered parameter type, know
+// how to convert themselves; everything else is converted by
+// convertArg.
+func (reg *Registry) convertGroup(r *runnerAndArgs, groupIdx int, to reflect.Type) (reflect.Value, error) {
+	var param *parameterType
+	if groupIdx < len(r.Params) {
+		param = r.Params[groupIdx]
+	}
+	if param == nil {
+		param, _ = reg.parameterTypeFor(to)
+	}
+
+	if param != nil {
+		return param.convert(r.Args[groupIdx], r.Language, to)
+	}
+	return convertArg(r.Args[groupIdx], r.matched(groupIdx), r.Language, to)
+}
+
+// isGroupStruct reports whether named groups should be bound to the
+// fields of to, rather than to being converted from a single group.
+func (reg *Registry) isGroupStruct(to reflect.Type) bool {
+	if to.Kind() != reflect.Struct || to == timeType {
+		return false
+	} else if _, ok := reg.parameterTypeFor(to); ok {
+		return false
+	}
+	switch reflect.New(to).Interface().(type) {
+	case encoding.TextUnmarshaler, flag.Value:
+		return false
+	}
+	return true
+}
+
+// bindGroups fills the fields of a new struct of type to from the
+// named groups of r. A field is filled by the group named in its
+// gorkin tag, e.g. `gorkin:"count"`, or else by the group whose name
+// matches the field's name ignoring case. Fields tagged `gorkin:"-"`
+// and fields without a group are left alone. Every named group must
+// have a field.
+func (reg *Registry) bindGroups(r *runnerAndArgs, to reflect.Type) (reflect.Value, error) {
+	val := reflect.New(to).Elem()
+
+	for groupIdx, groupName := range r.Names {
+		if groupName == "" {
+			continue
+		}
+		fieldIdx, ok := fieldFor(to, groupName)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf(
+				"%s: %s: the group named %q has no field in %v",
+				r.Location,
+				r.Line,
+				groupName,
+				to,
+			)
+		}
+		field := to.Field(fieldIdx)
+		arg, err := reg.convertGroup(r, groupIdx, field.Type)
+		if err != nil {
+			return reflect.Value{}, r.argError(groupIdx, fmt.Errorf("field %s: %v", field.Name, err))
+		}
+		val.Field(fieldIdx).Set(arg)
+	}
+	return val, nil
+}
+
+// fieldFor returns the index of the exported field of the struct type
+// t which is named name, either by its gorkin tag or, ignoring case,
+// by its field name. Fields tagged `gorkin:"-"` are never returned.
+func fieldFor(t reflect.Type, name string) (int, bool) {
+	for fieldIdx := 0; fieldIdx < t.NumField(); fieldIdx++ {
+		field := t.Field(fieldIdx)
+		if field.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+
+		fieldName := field.Name
+		if tag := field.Tag.Get("gorkin"); tag == "-" {
+			continue
+		} else if tag != "" {
+			fieldName = tag
+		}
+		if strings.EqualFold(fieldName, name) {
+			return fieldIdx, true
+		}
+	}
+	return 0, false
+}
+
+// variadicArg builds the slice of type to passed to a variadic step's
+// final parameter from the groups of r starting at fromGroup. Each
+// group which took part in the match becomes an element, unless there
+// is only one group, in which case it is split as a list such as
+// `"a", "b" and "c"`.
+func (reg *Registry) variadicArg(r *runnerAndArgs, fromGroup int, to reflect.Type) (reflect.Value, error) {
+	// Parameters before the variadic one may have used up every
+	// group, leaving it an empty slice.
+	remaining := len(r.Names) - fromGroup
+	if remaining < 0 {
+		remaining = 0
+	}
+	slice := reflect.MakeSlice(to, 0, remaining)
+	elemType := to.Elem()
+
+	// Lists are only split from plain regex groups or the anonymous
+	// {} parameter.
+	splittable := fromGroup >= len(r.Params) || r.Params[fromGroup] == nil || r.Params[fromGroup].Name == ""
+	if remaining == 1 && splittable {
+		if !r.matched(fromGroup) {
+			return slice, nil
+		}
+		for _, item := range splitList(r.Args[fromGroup]) {
+			var elem reflect.Value
+			var err error
+			if param, ok := reg.parameterTypeFor(elemType); ok {
+				elem, err = param.convert(item, r.Language, elemType)
+			} else {
+				elem, err = convertArg(item, true, r.Language, elemType)
+			}
+			if err != nil {
+				return reflect.Value{}, r.argError(fromGroup, err)
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		return slice, nil
+	}
+
+	for groupIdx := fromGroup; groupIdx < len(r.Names); groupIdx++ {
+		if !r.matched(groupIdx) {
+			continue
+		}
+		elem, err := reg.convertGroup(r, groupIdx, elemType)
+		if err != nil {
+			return reflect.Value{}, r.argError(groupIdx, err)
+		}
+		slice = reflect.Append(slice, elem)
+	}
+	return slice, nil
+}
+
+// splitList splits text such as `"a", "b" and "c"` into its items,
+// removing any quotes around them. Commas and the word "and" separate
+// items unless they're quoted.
+func splitList(text string) []string {
+	var items []string
+	var item []rune
+	var quote rune
+
+	flush := func() {
+		if s := strings.TrimSpace(string(item)); s != "" {
+			if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+				s = s[1 : len(s)-1]
+			}
+			items = append(items, s)
+		}
+		item = nil
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			item = append(item, c)
+		case (c == '"' || c == '\'') && strings.TrimSpace(string(item)) == "":
+			quote = c
+			item = append(item, c)
+		case c == ',':
+			flush()
+		case isWordAt(runes, i, "and"):
+			flush()
+			i += len("and") - 1
+		default:
+			item = append(item, c)
+		}
+	}
+	flush()
+	return items
+}
+
+// isWordAt reports whether word appears in runes at i on its own,
+// rather than as part of a longer word.
+func isWordAt(runes []rune, i int, word string) bool {
+	end := i + len(word)
+	if end > len(runes) || string(runes[i:end]) != word {
+		return false
+	}
+	return (i == 0 || unicode.IsSpace(runes[i-1])) && (end == len(runes) || unicode.IsSpace(runes[end]))
+}
@@ -0,0 +1,261 @@
+package gorkin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterFormatter("cucumber", func(w io.Writer) Formatter {
+		return &cucumberFormatter{w: w}
+	})
+}
+
+// The elements of a Cucumber JSON report, as read by Cucumber's report
+// generators.
+type (
+	cucumberFeature struct {
+		URI         string             `json:"uri"`
+		ID          string             `json:"id"`
+		Keyword     string             `json:"keyword"`
+		Name        string             `json:"name"`
+		Description string             `json:"description"`
+		Line        int                `json:"line"`
+		Tags        []*cucumberTag     `json:"tags,omitempty"`
+		Elements    []*cucumberElement `json:"elements"`
+	}
+
+	cucumberElement struct {
+		ID          string          `json:"id"`
+		Keyword     string          `json:"keyword"`
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Line        int             `json:"line"`
+		Type        string          `json:"type"`
+		Tags        []*cucumberTag  `json:"tags,omitempty"`
+		Steps       []*cucumberStep `json:"steps"`
+	}
+
+	cucumberTag struct {
+		Name string `json:"name"`
+		Line int    `json:"line"`
+	}
+
+	cucumberStep struct {
+		Keyword    string               `json:"keyword"`
+		Name       string               `json:"name"`
+		Line       int                  `json:"line"`
+		Match      *cucumberMatch       `json:"match,omitempty"`
+		Result     *cucumberResult      `json:"result"`
+		Embeddings []*cucumberEmbedding `json:"embeddings,omitempty"`
+	}
+
+	cucumberMatch struct {
+		Location string `json:"location"`
+	}
+
+	cucumberResult struct {
+		Status string `json:"status"`
+		// Duration is in nanoseconds.
+		Duration     int64  `json:"duration,omitempty"`
+		ErrorMessage string `json:"error_message,omitempty"`
+	}
+
+	cucumberEmbedding struct {
+		MimeType string `json:"mime_type"`
+		// Data is encoded in base64.
+		Data string `json:"data"`
+	}
+)
+
+// cucumberFormatter writes a Cucumber JSON report once the run has
+// finished. Each scenario is an element of its feature, with the
+// background's steps leading its own. Steps run with RunStep aren't
+// listed, since the format has no place for them; their failures fail
+// the step which ran them. The steps of a feature which couldn't be
+// parsed or matched are listed as written, with those which match no
+// step definition undefined and the rest skipped.
+type cucumberFormatter struct {
+	BaseFormatter
+	w io.Writer
+
+	features []*cucumberFeature
+	feature  *cucumberFeature
+	element  *cucumberElement
+	// parsed holds the elements of the current feature as written, for
+	// when it can't be run.
+	parsed []*cucumberElement
+	failed bool
+	// tags holds the tags read since the last declaration, and
+	// scenarioTags the tags declared above each scenario, by the
+	// scenario's line.
+	tags         []*cucumberTag
+	scenarioTags map[int][]*cucumberTag
+}
+
+func (f *cucumberFormatter) FeatureStarted(info *FeatureInfo) {
+	f.feature = &cucumberFeature{
+		URI:      info.Path,
+		ID:       cucumberID(info.Name),
+		Keyword:  "Feature",
+		Name:     info.Name,
+		Elements: []*cucumberElement{},
+	}
+	f.parsed = nil
+	f.failed = false
+	f.tags = nil
+	f.scenarioTags = make(map[int][]*cucumberTag)
+}
+
+func (f *cucumberFormatter) LineParsed(l *LineInfo) {
+	line := locationLine(l.Location)
+	if l.Err != nil {
+		f.failed = true
+	}
+
+	switch {
+	case strings.HasPrefix(l.Text, "@"):
+		tags, _ := parseTags(l.Text)
+		for _, tag := range tags {
+			f.tags = append(f.tags, &cucumberTag{Name: tag, Line: line})
+		}
+	case strings.HasPrefix(l.Text, "Feature:"):
+		f.feature.Line = line
+		f.feature.Tags, f.tags = f.tags, nil
+	case strings.HasPrefix(l.Text, "Scenario"), strings.HasPrefix(l.Text, "Background:"):
+		keyword, elementType := "Scenario", "scenario"
+		if strings.HasPrefix(l.Text, "Background:") {
+			keyword, elementType = "Background", "background"
+		}
+		f.scenarioTags[line], f.tags = f.tags, nil
+		f.parsed = append(f.parsed, &cucumberElement{
+			ID:      f.feature.ID + ";" + cucumberID(declarationName(l.Text)),
+			Keyword: keyword,
+			Name:    declarationName(l.Text),
+			Line:    line,
+			Type:    elementType,
+			Tags:    append(append([]*cucumberTag{}, f.feature.Tags...), f.scenarioTags[line]...),
+			Steps:   []*cucumberStep{},
+		})
+	case l.Definition != "" || l.Err != nil:
+		if len(f.parsed) == 0 {
+			f.parsed = append(f.parsed, &cucumberElement{Keyword: "Scenario", Type: "scenario", Steps: []*cucumberStep{}})
+		}
+		keyword, name := stepKeyword(l.Text)
+		step := &cucumberStep{
+			Keyword: keyword,
+			Name:    name,
+			Line:    line,
+			Result:  &cucumberResult{Status: "skipped"},
+		}
+		switch {
+		case l.Err == errNoMatchingRunner:
+			step.Result.Status = "undefined"
+		case l.Err != nil:
+			step.Result = &cucumberResult{Status: "failed", ErrorMessage: l.Err.Error()}
+		default:
+			step.Match = &cucumberMatch{Location: l.Definition}
+		}
+		element := f.parsed[len(f.parsed)-1]
+		element.Steps = append(element.Steps, step)
+	}
+}
+
+func (f *cucumberFormatter) ScenarioStarted(s *ScenarioInfo) {
+	line := locationLine(s.Location)
+	f.element = &cucumberElement{
+		ID:      f.feature.ID + ";" + cucumberID(s.Name),
+		Keyword: "Scenario",
+		Name:    s.Name,
+		Line:    line,
+		Type:    "scenario",
+		Tags:    append(append([]*cucumberTag{}, f.feature.Tags...), f.scenarioTags[line]...),
+		Steps:   []*cucumberStep{},
+	}
+}
+
+func (f *cucumberFormatter) StepFinished(s *StepInfo) {
+	if s.Parent != nil {
+		return
+	}
+
+	keyword, name := stepKeyword(s.Text)
+	step := &cucumberStep{
+		Keyword: keyword,
+		Name:    name,
+		Line:    locationLine(s.Location),
+		Result: &cucumberResult{
+			Status:   s.Status.String(),
+			Duration: s.Duration.Nanoseconds(),
+		},
+	}
+	if s.Definition != "" {
+		step.Match = &cucumberMatch{Location: s.Definition}
+	}
+	if s.Err != nil {
+		step.Result.ErrorMessage = s.Err.Error()
+	}
+	for _, a := range s.Attachments {
+		step.Embeddings = append(step.Embeddings, &cucumberEmbedding{
+			MimeType: a.MediaType,
+			Data:     base64.StdEncoding.EncodeToString(a.Data),
+		})
+	}
+	f.element.Steps = append(f.element.Steps, step)
+}
+
+func (f *cucumberFormatter) ScenarioFinished(s *ScenarioInfo) {
+	f.feature.Elements = append(f.feature.Elements, f.element)
+	f.element = nil
+}
+
+func (f *cucumberFormatter) FeatureFinished(info *FeatureInfo) {
+	if f.failed && len(f.feature.Elements) == 0 {
+		f.feature.Elements = append(f.feature.Elements, f.parsed...)
+	}
+	f.features = append(f.features, f.feature)
+	f.feature = nil
+}
+
+func (f *cucumberFormatter) SuiteFinished() {
+	features := f.features
+	if features == nil {
+		features = []*cucumberFeature{}
+	}
+
+	enc := json.NewEncoder(f.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(features); err != nil {
+		log.Printf("could not write Cucumber JSON report: %v", err)
+	}
+}
+
+// stepKeyword splits the text of a step into its keyword, followed by
+// a space as Cucumber reports it, and the rest of the step.
+func stepKeyword(text string) (string, string) {
+	fields := strings.SplitN(text, " ", 2)
+	if len(fields) < 2 {
+		return "", text
+	}
+	return fields[0] + " ", fields[1]
+}
+
+// locationLine returns the line number of a location such as
+// "login.feature:12", or 0 if it has none.
+func locationLine(location string) int {
+	line, err := strconv.Atoi(location[strings.LastIndex(location, ":")+1:])
+	if err != nil {
+		return 0
+	}
+	return line
+}
+
+// cucumberID makes an id for a Cucumber JSON report out of a name:
+// lower case, with dashes for spaces.
+func cucumberID(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
@@ -0,0 +1,246 @@
+package gorkin
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// docString is a doc string which follows a step in a feature, e.g.:
+//
+//	Given the request
+//	"""json
+//	{"name": "bob"}
+//	"""
+type docString struct {
+	// Content is the text between the delimiters.
+	Content string
+	// MediaType is the text following the opening delimiter, e.g.
+	// json.
+	MediaType string
+	// Path is the feature file the doc string is in.
+	Path string
+	// Line is the line of the feature file which Content begins on.
+	Line int
+}
+
+// isDocStringTarget reports whether a step's parameter of type t
+// should receive its doc string decoded according to the doc
+// string's media type.
+func isDocStringTarget(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return t != timeType
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// decode decodes the doc string into a new value of type to according
+// to its media type:
+//
+//	json       encoding/json
+//	xml        encoding/xml
+//	csv        a slice of structs or maps, using the first row as
+//	           the header, or a [][]string
+//	yaml, kv   "key: value" lines into a struct or map
+//
+// Doc strings without a media type are decoded as json or xml if they
+// look like either.
+func (d *docString) decode(to reflect.Type) (reflect.Value, error) {
+	ptr := reflect.New(to)
+	target := allocate(ptr.Elem())
+
+	var err error
+	var errLine int
+	switch mediaType := d.mediaType(); mediaType {
+	default:
+		return reflect.Value{}, fmt.Errorf(
+			`%s:%d: cannot decode a doc string with media type %q into %v; use json, xml, csv or yaml`,
+			d.Path, d.Line-1, mediaType, to,
+		)
+	case "json":
+		if err = json.Unmarshal([]byte(d.Content), ptr.Interface()); err != nil {
+			switch jsonErr := err.(type) {
+			case *json.SyntaxError:
+				errLine = lineOfOffset(d.Content, jsonErr.Offset)
+			case *json.UnmarshalTypeError:
+				errLine = lineOfOffset(d.Content, jsonErr.Offset)
+			}
+		}
+	case "xml":
+		if err = xml.Unmarshal([]byte(d.Content), ptr.Interface()); err != nil {
+			if xmlErr, ok := err.(*xml.SyntaxError); ok {
+				errLine = xmlErr.Line
+			}
+		}
+	case "csv":
+		errLine, err = decodeCSV(d.Content, target)
+	case "yaml", "yml", "kv":
+		errLine, err = decodeKeyValues(d.Content, target)
+	}
+
+	if err != nil {
+		line := d.Line
+		if errLine > 0 {
+			line += errLine - 1
+		}
+		return reflect.Value{}, fmt.Errorf("%s:%d: cannot decode %s doc string into %v: %v",
+			d.Path, line, d.mediaType(), to, err,
+		)
+	}
+	return ptr.Elem(), nil
+}
+
+// mediaType returns the doc string's media type without any
+// "application/" or "text/" prefix, guessing json or xml when none
+// was given.
+func (d *docString) mediaType() string {
+	mediaType := strings.ToLower(d.MediaType)
+	for _, prefix := range []string{"application/", "text/"} {
+		mediaType = strings.TrimPrefix(mediaType, prefix)
+	}
+	if mediaType != "" {
+		return mediaType
+	}
+
+	switch content := strings.TrimSpace(d.Content); {
+	case strings.HasPrefix(content, "{"), strings.HasPrefix(content, "["):
+		return "json"
+	case strings.HasPrefix(content, "<"):
+		return "xml"
+	}
+	return ""
+}
+
+// lineOfOffset returns the 1-based line which offset falls on.
+func lineOfOffset(content string, offset int64) int {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+// allocate points val, and anything it points to, at new values until
+// it reaches one which isn't a pointer, and returns that value.
+func allocate(val reflect.Value) reflect.Value {
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		val = val.Elem()
+	}
+	return val
+}
+
+// decodeCSV decodes content into val, which must be a [][]string or a
+// slice of structs or maps, or of pointers to them. It returns the line of any error.
+func decodeCSV(content string, val reflect.Value) (int, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		if csvErr, ok := err.(*csv.ParseError); ok {
+			return csvErr.Line, csvErr.Err
+		}
+		return 0, err
+	}
+
+	if val.Kind() != reflect.Slice {
+		return 0, fmt.Errorf("csv can only be decoded into a slice")
+	}
+	if val.Type() == reflect.TypeOf([][]string{}) {
+		val.Set(reflect.ValueOf(records))
+		return 0, nil
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	header, rows := records[0], records[1:]
+	elemType := val.Type().Elem()
+	for rowIdx, row := range rows {
+		line := rowIdx + 2
+		elem := reflect.New(elemType).Elem()
+		fields := allocate(elem)
+		for colIdx, key := range header {
+			if colIdx >= len(row) {
+				break
+			}
+			if err := setField(fields, key, row[colIdx]); err != nil {
+				return line, err
+			}
+		}
+		val.Set(reflect.Append(val, elem))
+	}
+	return 0, nil
+}
+
+// decodeKeyValues decodes "key: value" lines into val, which must be
+// a struct or a map with string keys. Blank lines and lines beginning
+// with # are ignored. It returns the line of any error.
+func decodeKeyValues(content string, val reflect.Value) (int, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		sep := strings.Index(text, ":")
+		if sep < 0 {
+			return line, fmt.Errorf("expected \"key: value\", got %q", text)
+		}
+		key := strings.TrimSpace(text[:sep])
+		value := strings.Trim(strings.TrimSpace(text[sep+1:]), `"'`)
+		if err := setField(val, key, value); err != nil {
+			return line, err
+		}
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// setField converts value and stores it under key in val, which must
+// be a struct or a map with string keys. Struct fields are found the
+// same way as for named groups: by their gorkin tag, or else by their
+// name ignoring case. Keys without a field are an error.
+func setField(val reflect.Value, key, value string) error {
+	switch val.Kind() {
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot decode into %v; map keys must be strings", val.Type())
+		}
+		elem, err := convertArg(value, true, "", val.Type().Elem())
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		if val.IsNil() {
+			val.Set(reflect.MakeMap(val.Type()))
+		}
+		val.SetMapIndex(reflect.ValueOf(key).Convert(val.Type().Key()), elem)
+		return nil
+	case reflect.Struct:
+		fieldIdx, ok := fieldFor(val.Type(), key)
+		if !ok {
+			return fmt.Errorf("%v has no field for %q", val.Type(), key)
+		}
+		elem, err := convertArg(value, true, "", val.Type().Field(fieldIdx).Type)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		val.Field(fieldIdx).Set(elem)
+		return nil
+	}
+	return fmt.Errorf("cannot decode into %v", val.Type())
+}
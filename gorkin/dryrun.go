@@ -0,0 +1,72 @@
+package gorkin
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dryRunFeatures parses the features at paths and matches their steps
+// as a normal run would, and builds the arguments of every step to
+// check that they can be converted, but calls no steps, hooks or
+// constructors. Undefined and ambiguous steps, and steps whose
+// arguments don't suit their functions, fail t. The formatters are
+// told of every scenario and step as if it ran: steps are skipped,
+// or pending if their function is Pending. Steps run with RunStep
+// can't be checked this way.
+func dryRunFeatures(reg *Registry, t *testing.T, paths []string, stepType reflect.Type) {
+	for _, path := range paths {
+		ftr, err := readFeature(reg, path)
+		if err != nil {
+			reportFeatureError(t, err, stepType)
+			reg.format.FeatureFinished(ftr.Info)
+			continue
+		}
+
+		for _, scenario := range splitScenarios(ftr.Runners) {
+			dryRunScenario(reg, t, ftr.Info, scenario, ftr.Background, stepType)
+		}
+		reg.format.FeatureFinished(ftr.Info)
+	}
+
+	reg.reportUnusedSteps(t)
+}
+
+// dryRunScenario checks the steps of a scenario without running them.
+// See dryRunFeatures.
+func dryRunScenario(reg *Registry, t *testing.T, featureInfo *FeatureInfo, scenario, backgroundSteps []*runnerAndArgs, stepType reflect.Type) {
+	scenarioInfo, steps := newScenarioInfo(featureInfo, scenario, backgroundSteps)
+	scenarioInfo.Status = StatusSkipped
+	reg.format.ScenarioStarted(scenarioInfo)
+
+	for _, r := range steps {
+		// Provided values are zero outside of a running scenario,
+		// so no constructor is called.
+		sc := &scope{
+			T:         t,
+			StepType:  stepType,
+			StepVal:   reflect.New(stepType.Elem()),
+			Scenario:  scenarioInfo,
+			Registry:  reg,
+			Language:  r.Language,
+			Providers: reg.providers,
+		}
+
+		stepInfo := newStepInfo(r, sc)
+		stepInfo.Status = StatusSkipped
+		reg.format.StepStarted(stepInfo)
+		if _, err := stepArgs(reg, r, sc); err != nil {
+			t.Error(err)
+			stepInfo.Status = StatusFailed
+			stepInfo.Err = err
+			scenarioInfo.Status = StatusFailed
+		} else if r.isPending() {
+			stepInfo.Status = StatusPending
+			if scenarioInfo.Status != StatusFailed {
+				scenarioInfo.Status = StatusPending
+			}
+		}
+		reg.format.StepFinished(stepInfo)
+	}
+
+	reg.format.ScenarioFinished(scenarioInfo)
+}
@@ -0,0 +1,469 @@
+package gorkin
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// parameterType describes a parameter which can be referenced by
+// name, e.g. {int}, from a Cucumber Expression.
+type parameterType struct {
+	// Name is how the parameter type is referenced within braces.
+	Name string
+	// Regex matches the text of the parameter.
+	Regex string
+	// Type is the Go type the text of the parameter is converted to.
+	Type reflect.Type
+	// Converter is the function registered to convert the text, by
+	// which registering the same parameter type again is recognised.
+	// It is nil for the built-in parameter types.
+	Converter interface{}
+	// transform converts the text of the parameter into a value of
+	// Type.
+	transform func(string) (reflect.Value, error)
+}
+
+// convert converts text into a value which can be passed to a step's
+// parameter of type to. language is the language of the feature the
+// text came from.
+func (p *parameterType) convert(text, language string, to reflect.Type) (reflect.Value, error) {
+	v, err := p.transform(text)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("could not convert %q to {%s}: %v", text, p.Name, err)
+	}
+
+	switch {
+	case v.Type().AssignableTo(to):
+		return v, nil
+	case isNumeric(v.Type()) && isNumeric(to):
+		return convertNumber(text, v, to)
+	case v.Kind() == reflect.String && to.Kind() == reflect.String:
+		return v.Convert(to), nil
+	case v.Kind() == reflect.String:
+		// e.g. a {word} passed to a time.Duration.
+		return convertArg(v.String(), true, language, to)
+	}
+	return reflect.Value{}, fmt.Errorf("{%s} cannot be passed to a parameter of type %v", p.Name, to)
+}
+
+// convertNumber converts v, the value of a parameter whose text was
+// text, to the numeric type to. Values which to can't hold exactly
+// fail as they would if text were converted by convertArg, rather
+// than wrapping around or being truncated.
+func convertNumber(text string, v reflect.Value, to reflect.Type) (reflect.Value, error) {
+	out := reflect.New(to).Elem()
+	if v.CanFloat() && (out.CanInt() || out.CanUint()) && v.Float() != math.Trunc(v.Float()) {
+		return reflect.Value{}, numError(text, to, strconv.ErrSyntax)
+	}
+
+	var fits bool
+	switch {
+	case out.CanInt():
+		switch {
+		case v.CanInt():
+			fits = !out.OverflowInt(v.Int())
+		case v.CanUint():
+			fits = v.Uint() <= math.MaxInt64 && !out.OverflowInt(int64(v.Uint()))
+		default:
+			f := v.Float()
+			fits = f >= math.MinInt64 && f < math.MaxInt64 && !out.OverflowInt(int64(f))
+		}
+	case out.CanUint():
+		switch {
+		case v.CanInt():
+			fits = v.Int() >= 0 && !out.OverflowUint(uint64(v.Int()))
+		case v.CanUint():
+			fits = !out.OverflowUint(v.Uint())
+		default:
+			f := v.Float()
+			fits = f >= 0 && f < math.MaxUint64 && !out.OverflowUint(uint64(f))
+		}
+	default:
+		fits = !v.CanFloat() || !out.OverflowFloat(v.Float())
+	}
+
+	if !fits {
+		return reflect.Value{}, numError(text, to, strconv.ErrRange)
+	}
+	return v.Convert(to), nil
+}
+
+func isNumeric(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// builtinParameterTypes are the parameter types available to every
+// Cucumber Expression.
+var builtinParameterTypes = map[string]*parameterType{
+	"int": {
+		Name:  "int",
+		Regex: `-?\d+`,
+		Type:  reflect.TypeOf(0),
+		transform: func(s string) (reflect.Value, error) {
+			i, err := strconv.Atoi(s)
+			return reflect.ValueOf(i), err
+		},
+	},
+	"float": {
+		Name:  "float",
+		Regex: `[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`,
+		Type:  reflect.TypeOf(0.0),
+		transform: func(s string) (reflect.Value, error) {
+			f, err := strconv.ParseFloat(s, 64)
+			return reflect.ValueOf(f), err
+		},
+	},
+	"word": {
+		Name:  "word",
+		Regex: `[^\s]+`,
+		Type:  reflect.TypeOf(""),
+		transform: func(s string) (reflect.Value, error) {
+			return reflect.ValueOf(s), nil
+		},
+	},
+	"string": {
+		Name:  "string",
+		Regex: `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+		Type:  reflect.TypeOf(""),
+		transform: func(s string) (reflect.Value, error) {
+			// Strip the quotes and any escapes of them.
+			quote := s[:1]
+			s = s[1 : len(s)-1]
+			s = strings.Replace(s, `\`+quote, quote, -1)
+			return reflect.ValueOf(s), nil
+		},
+	},
+	"bigint": {
+		Name:  "bigint",
+		Regex: `-?\d+`,
+		Type:  reflect.TypeOf(new(big.Int)),
+		transform: func(s string) (reflect.Value, error) {
+			i, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("not an integer")
+			}
+			return reflect.ValueOf(i), nil
+		},
+	},
+	"": {
+		Name:  "",
+		Regex: `.*`,
+		Type:  reflect.TypeOf(""),
+		transform: func(s string) (reflect.Value, error) {
+			return reflect.ValueOf(s), nil
+		},
+	},
+}
+
+// newParameterType validates convert, which must be of the form
+// func(string) (T, error), and returns a parameter type which uses it.
+func newParameterType(name, regex string, convert interface{}) (*parameterType, error) {
+	if strings.ContainsAny(name, "{}()\\/ \t") {
+		return nil, fmt.Errorf("Parameter type names may not contain braces, parentheses, slashes or whitespace: %q", name)
+	} else if _, err := regexp.Compile(regex); err != nil {
+		return nil, fmt.Errorf("Parameter type {%s}: %v", name, err)
+	}
+
+	convertVal := reflect.ValueOf(convert)
+	convertType := reflect.TypeOf(convert)
+	if convertType == nil || convertType.Kind() != reflect.Func ||
+		convertType.NumIn() != 1 || convertType.In(0).Kind() != reflect.String ||
+		convertType.NumOut() != 2 || convertType.Out(1) != errorType {
+		return nil, fmt.Errorf("Parameter type {%s} must convert with a func(string) (T, error), not %v", name, convertType)
+	}
+
+	return &parameterType{
+		Name:      name,
+		Regex:     regex,
+		Type:      convertType.Out(0),
+		Converter: convert,
+		transform: func(s string) (reflect.Value, error) {
+			out := convertVal.Call([]reflect.Value{reflect.ValueOf(s).Convert(convertType.In(0))})
+			if err, _ := out[1].Interface().(error); err != nil {
+				return reflect.Value{}, err
+			}
+			return out[0], nil
+		},
+	}, nil
+}
+
+// parameterType returns the parameter type referred to by name.
+func (r *Registry) parameterType(name string) (*parameterType, bool) {
+	if p, ok := builtinParameterTypes[name]; ok {
+		return p, true
+	}
+	for _, p := range r.paramTypes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// parameterTypeFor returns the first registered custom parameter type
+// which converts to t. This allows groups in regexes to be converted
+// by custom parameter types. Predeclared types such as string and int
+// are never converted this way, so that a custom parameter type which
+// produces one doesn't capture every such argument.
+func (r *Registry) parameterTypeFor(t reflect.Type) (*parameterType, bool) {
+	if t.Name() != "" && t.PkgPath() == "" {
+		return nil, false
+	}
+	for _, p := range r.paramTypes {
+		if p.Type == t {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// parameterReference finds references to parameter types, e.g. {int},
+// which aren't escaped.
+var parameterReference = regexp.MustCompile(`(^|[^\\])\{([^{}()\\/\s]*)\}`)
+
+// repetition matches the body of a regex's counted repetition, e.g.
+// the 2,3 of a{2,3}.
+var repetition = regexp.MustCompile(`^\d+(,\d*)?$`)
+
+// isExpression reports whether pattern should be treated as a Cucumber
+// Expression rather than a regex. Patterns which begin with ^ or end
+// with $ are always regexes. Otherwise, any pattern which refers to a
+// parameter type is an expression. References to parameter types
+// which don't exist count too, so that they fail to compile rather
+// than quietly matching as a regex.
+func (r *Registry) isExpression(pattern string) bool {
+	if strings.HasPrefix(pattern, "^") || strings.HasSuffix(pattern, "$") {
+		return false
+	}
+	for _, ref := range parameterReference.FindAllStringSubmatch(pattern, -1) {
+		if _, ok := r.parameterType(ref[2]); ok || !repetition.MatchString(ref[2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileExpression compiles a Cucumber Expression into a regex with
+// one group for each parameter the expression refers to. The
+// parameter types are returned in the order of their groups.
+func (r *Registry) compileExpression(expr string) (string, []*parameterType, error) {
+	regex := new(bytes.Buffer)
+	var params []*parameterType
+
+	// Alternation applies to runs of text bounded by whitespace, so
+	// compile each of those separately.
+	for _, word := range splitWords(expr) {
+		if strings.TrimSpace(word) == "" {
+			regex.WriteString(regexp.QuoteMeta(word))
+			continue
+		}
+
+		alternatives := splitUnescaped(word, '/')
+		if len(alternatives) == 1 {
+			wordRegex, wordParams, err := r.compileAlternative(word)
+			if err != nil {
+				return "", nil, err
+			}
+			regex.WriteString(wordRegex)
+			params = append(params, wordParams...)
+			continue
+		}
+
+		regex.WriteString("(?:")
+		for i, alternative := range alternatives {
+			if alternative == "" {
+				return "", nil, fmt.Errorf("Alternation in %q may not be empty.", expr)
+			}
+			altRegex, altParams, err := r.compileAlternative(alternative)
+			if err != nil {
+				return "", nil, err
+			} else if len(altParams) > 0 {
+				return "", nil, fmt.Errorf("Alternation in %q may not contain parameters.", expr)
+			}
+			if i > 0 {
+				regex.WriteString("|")
+			}
+			regex.WriteString(altRegex)
+		}
+		regex.WriteString(")")
+	}
+
+	return regex.String(), params, nil
+}
+
+// compileAlternative compiles text which contains no whitespace or
+// alternation.
+func (r *Registry) compileAlternative(text string) (string, []*parameterType, error) {
+	regex := new(bytes.Buffer)
+	var params []*parameterType
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		default:
+			regex.WriteString(regexp.QuoteMeta(string(c)))
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				regex.WriteString(regexp.QuoteMeta(string(runes[i])))
+			} else {
+				regex.WriteString(regexp.QuoteMeta(`\`))
+			}
+		case '(':
+			end := indexUnescaped(runes, i+1, ')')
+			if end < 0 {
+				return "", nil, fmt.Errorf("Optional text in %q is missing a closing ).", text)
+			}
+			optional := string(runes[i+1 : end])
+			if strings.ContainsAny(optional, "{}") {
+				return "", nil, fmt.Errorf("Optional text in %q may not contain parameters.", text)
+			}
+			regex.WriteString("(?:" + regexp.QuoteMeta(unescape(optional)) + ")?")
+			i = end
+		case '{':
+			end := indexUnescaped(runes, i+1, '}')
+			if end < 0 {
+				return "", nil, fmt.Errorf("Parameter in %q is missing a closing }.", text)
+			}
+			name := string(runes[i+1 : end])
+			param, ok := r.parameterType(name)
+			if !ok {
+				return "", nil, fmt.Errorf("Undefined parameter type {%s}.", name)
+			}
+			paramRegex, err := nonCapturing(param.Regex)
+			if err != nil {
+				return "", nil, fmt.Errorf("Parameter type {%s}: %v", name, err)
+			}
+			regex.WriteString("(" + paramRegex + ")")
+			params = append(params, param)
+			i = end
+		}
+	}
+
+	return regex.String(), params, nil
+}
+
+// nonCapturing rewrites regex so that it has no capturing groups, so
+// that it can be embedded within an expression without disturbing
+// the position of the groups which follow it.
+func nonCapturing(regex string) (string, error) {
+	re, err := syntax.Parse(regex, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	var strip func(*syntax.Regexp) *syntax.Regexp
+	strip = func(re *syntax.Regexp) *syntax.Regexp {
+		for i, sub := range re.Sub {
+			re.Sub[i] = strip(sub)
+		}
+		if re.Op == syntax.OpCapture {
+			return re.Sub[0]
+		}
+		return re
+	}
+	return "(?:" + strip(re).String() + ")", nil
+}
+
+// splitWords splits s into runs of whitespace and runs of everything
+// else, keeping both. Whitespace within parentheses or braces is not
+// split upon.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	inSpace, depth := false, 0
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		isSpace := depth == 0 && (c == ' ' || c == '\t')
+		if len(word) > 0 && isSpace != inSpace {
+			words = append(words, string(word))
+			word = nil
+		}
+		inSpace = isSpace
+		word = append(word, c)
+		switch c {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				word = append(word, runes[i])
+			}
+		case '(', '{':
+			depth++
+		case ')', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// splitUnescaped splits s around each sep which isn't escaped or
+// within parentheses.
+func splitUnescaped(s string, sep rune) []string {
+	var parts []string
+	var part []rune
+	depth := 0
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == '\\' && i+1 < len(runes):
+			part = append(part, c, runes[i+1])
+			i++
+		case c == sep && depth == 0:
+			parts = append(parts, string(part))
+			part = nil
+		default:
+			if c == '(' {
+				depth++
+			} else if c == ')' && depth > 0 {
+				depth--
+			}
+			part = append(part, c)
+		}
+	}
+	return append(parts, string(part))
+}
+
+// indexUnescaped returns the index of the first c at or after start
+// which isn't escaped, or -1.
+func indexUnescaped(runes []rune, start int, c rune) int {
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape removes the backslashes from escaped characters.
+func unescape(s string) string {
+	var unescaped []rune
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			i++
+		}
+		unescaped = append(unescaped, runes[i])
+	}
+	return string(unescaped)
+}
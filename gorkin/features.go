@@ -3,18 +3,17 @@ package gorkin
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
+	"regexp/syntax"
 	"strings"
 )
 
-// Steps are global for maximum reusability.
-var steps runnerMap = make(map[*regexp.Regexp]runner)
-
 func ParseFeature(featureLine string, reader *bufio.Reader) (*Feature, error) {
 
-	description := strings.TrimSpace(strings.TrimLeft(featureLine, "Feature:"))
+	description := strings.TrimSpace(strings.TrimPrefix(featureLine, "Feature:"))
 	if description == "" {
 		return nil, fmt.Errorf("Please provide a description for this feature.")
 	}
@@ -58,12 +57,32 @@ type Background struct {
 }
 
 func ParseGiven(givenLine string, reader *bufio.Reader) (*runnerAndArgs, error) {
-	return findRunner(strings.TrimLeft(givenLine, "Given "), steps, reader)
+	return DefaultRegistry.parseStep(givenLine, reader)
 }
 
+// Step registers f with DefaultRegistry to be run for any step which
+// matches regex.
 func Step(regex string, f runner) {
-	must(runnerExists(regex, steps))
-	steps[regexp.MustCompile(regex)] = f
+	DefaultRegistry.Step(regex, f)
+}
+
+// StepExpression registers f with DefaultRegistry to be run for any
+// step which matches the Cucumber Expression expr. See
+// Registry.StepExpression.
+func StepExpression(expr string, f runner) {
+	DefaultRegistry.StepExpression(expr, f)
+}
+
+// RegisterMethods registers the methods of steps with
+// DefaultRegistry. See Registry.RegisterMethods.
+func RegisterMethods(steps StepPatterner) {
+	DefaultRegistry.RegisterMethods(steps)
+}
+
+// ParameterType registers a parameter type with DefaultRegistry. See
+// Registry.ParameterType.
+func ParameterType(name, regex string, convert interface{}) {
+	DefaultRegistry.ParameterType(name, regex, convert)
 }
 
 func UsingScenario(description string) *Scenario {
@@ -82,11 +101,43 @@ type Scenario struct {
 }
 
 func ParseWhen(whenLine string, reader *bufio.Reader) (*runnerAndArgs, error) {
-	return findRunner(strings.TrimLeft(whenLine, "When "), steps, reader)
+	return DefaultRegistry.parseStep(whenLine, reader)
 }
 
 func ParseThen(thenLine string, reader *bufio.Reader) (*runnerAndArgs, error) {
-	return findRunner(strings.TrimLeft(thenLine, "Then "), steps, reader)
+	return DefaultRegistry.parseStep(thenLine, reader)
+}
+
+// parseStep finds the step which matches line, and records that the
+// step has been used. When line is ambiguous, every step which
+// matched it is recorded, since none of them is unused.
+func (r *Registry) parseStep(line string, reader *bufio.Reader) (*runnerAndArgs, error) {
+	runner, err := findRunner(stepText(line), r.steps, r.Options, reader)
+	if r.used == nil {
+		r.used = make(map[string]bool)
+	}
+	if err == nil {
+		r.used[runner.Step] = true
+	} else if ambiguous, ok := err.(*ambiguousStepError); ok {
+		for _, c := range ambiguous.Candidates {
+			r.used[c.Step] = true
+		}
+	}
+	return runner, err
+}
+
+// stepKeywords are the words which may begin a step in a feature.
+var stepKeywords = []string{"Given ", "When ", "Then ", "And ", "But ", "* "}
+
+// stepText returns line without the keyword which begins it. This is
+// the text which step patterns are matched against.
+func stepText(line string) string {
+	for _, keyword := range stepKeywords {
+		if strings.HasPrefix(line, keyword) {
+			return strings.TrimSpace(strings.TrimPrefix(line, keyword))
+		}
+	}
+	return line
 }
 
 func must(err error) {
@@ -95,36 +146,169 @@ func must(err error) {
 	}
 }
 
-func runnerExists(regex string, runners runnerMap) error {
+func runnerExists(regex string, defs []*stepDefinition) error {
 
-	for cRegex, _ := range runners {
-		if cRegex.String() == regex {
+	for _, def := range defs {
+		if def.Pattern == regex {
 			return fmt.Errorf(`The step for "%s" already exists.`, regex)
 		}
 	}
 	return nil
 }
 
-func findRunner(line string, runners runnerMap, reader *bufio.Reader) (found *runnerAndArgs, err error) {
+// stepDefinition is a step registered with a Registry.
+type stepDefinition struct {
+	// Pattern is the regex or Cucumber Expression the step was
+	// registered with.
+	Pattern string
+	// Expression is true when Pattern is a Cucumber Expression.
+	Expression bool
+	// Regex is Pattern compiled as it was written.
+	Regex *regexp.Regexp
+	// Anchored is Pattern compiled so that it must match the whole
+	// text of a step.
+	Anchored *regexp.Regexp
+	// Params holds the parameter type of each of Regex's groups, or
+	// nil for groups which were written as part of a regex.
+	Params []*parameterType
+	// Runner is the function to run when the step matches.
+	Runner runner
+}
+
+// matcher returns the regex used to match the text of steps against
+// this definition under opts.
+func (d *stepDefinition) matcher(opts Options) *regexp.Regexp {
+	if opts.Unanchored && !d.Expression {
+		return d.Regex
+	}
+	return d.Anchored
+}
+
+// Location returns the file and line at which the step's function
+// was defined.
+func (d *stepDefinition) Location() string {
+	return funcLocation(d.Runner)
+}
+
+// specificity scores how specific the step's pattern is by counting
+// the literal characters any matching text must contain.
+func (d *stepDefinition) specificity() int {
+	re, err := syntax.Parse(d.Regex.String(), syntax.Perl)
+	if err != nil {
+		return 0
+	}
+	return literalLength(re.Simplify())
+}
+
+func literalLength(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCapture, syntax.OpPlus:
+		return literalLength(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * literalLength(re.Sub[0])
+	case syntax.OpConcat:
+		n := 0
+		for _, sub := range re.Sub {
+			n += literalLength(sub)
+		}
+		return n
+	case syntax.OpAlternate:
+		n := -1
+		for _, sub := range re.Sub {
+			if l := literalLength(sub); n < 0 || l < n {
+				n = l
+			}
+		}
+		return n
+	}
+	return 0
+}
 
-	for cRegex, runner := range runners {
+// errNoMatchingRunner is returned by findRunner for lines which no
+// step matches.
+var errNoMatchingRunner = errors.New("No matching runner.")
 
-		if matches := cRegex.FindStringSubmatch(line); len(matches) != 0 {
-			if found != nil {
-				// TODO(kate): Give more information.
-				// TODO(kate): Could we detect this during definition?
-				// e.g. If one regex is a subset of another?
-				return nil, fmt.Errorf("Conflicting runners!")
+// findRunner looks for the step definition which matches line. Steps
+// are considered in the order they were registered. When more than
+// one step matches, opts.MatchPolicy decides which one wins.
+func findRunner(line string, defs []*stepDefinition, opts Options, reader *bufio.Reader) (*runnerAndArgs, error) {
+
+	var candidates []*runnerAndArgs
+	var candidateDefs []*stepDefinition
+	for _, def := range defs {
+		matcher := def.matcher(opts)
+		if loc := matcher.FindStringSubmatchIndex(line); loc != nil {
+			args, matched := submatches(line, loc)
+			offsets := make([]int, len(args))
+			for i := range offsets {
+				offsets[i] = loc[2*i+2]
 			}
+			candidates = append(candidates, &runnerAndArgs{
+				Runner:  def.Runner,
+				Args:    args,
+				Offsets: offsets,
+				Params:  def.Params,
+				Matched: matched,
+				Names:   matcher.SubexpNames()[1:],
+				Step:    def.Pattern,
+			})
+			candidateDefs = append(candidateDefs, def)
+		}
+	}
 
-			// Elide the master match at index 0.
-			found = &runnerAndArgs{runner, matches[1:], cRegex.String()}
+	switch {
+	case len(candidates) == 0:
+		return nil, errNoMatchingRunner
+	case len(candidates) == 1, opts.MatchPolicy == MatchFirstRegistered:
+		return candidates[0], nil
+	case opts.MatchPolicy == MatchMostSpecific:
+		best := 0
+		for i, def := range candidateDefs {
+			if def.specificity() > candidateDefs[best].specificity() {
+				best = i
+			}
 		}
+		return candidates[best], nil
 	}
 
-	if found == nil {
-		err = fmt.Errorf("No matching runner.")
+	// Registry.Check can find these before any feature is run.
+	return nil, &ambiguousStepError{Candidates: candidates, Defs: candidateDefs}
+}
+
+// ambiguousStepError is returned by findRunner for lines which more
+// than one step matches.
+type ambiguousStepError struct {
+	// Candidates are the matches of each step, in the order the
+	// steps were registered.
+	Candidates []*runnerAndArgs
+	// Defs are the definitions of the steps in Candidates.
+	Defs []*stepDefinition
+}
+
+func (e *ambiguousStepError) Error() string {
+	msg := new(bytes.Buffer)
+	fmt.Fprintf(msg, "Ambiguous step matches %d runners:", len(e.Candidates))
+	for i, c := range e.Candidates {
+		fmt.Fprintf(msg, "\n\t`%s` (%s) with groups %q", c.Step, e.Defs[i].Location(), c.Args)
 	}
+	return msg.String()
+}
 
-	return found, err
+// submatches returns the text of each group in loc, as returned by
+// FindStringSubmatchIndex, and whether the group took part in the
+// match.
+func submatches(line string, loc []int) ([]string, []bool) {
+	// Elide the master match at index 0.
+	numGroups := len(loc)/2 - 1
+	args := make([]string, numGroups)
+	matched := make([]bool, numGroups)
+	for i := range args {
+		if start, end := loc[2*i+2], loc[2*i+3]; start >= 0 {
+			args[i] = line[start:end]
+			matched[i] = true
+		}
+	}
+	return args, matched
 }
@@ -0,0 +1,63 @@
+package gorkin
+
+import (
+	"flag"
+	"strings"
+)
+
+// These flags are registered alongside go test's own so that they can
+// be passed to any test which runs features, e.g.:
+//
+//	go test ./features/steps/... -args -gorkin.check-steps
+var (
+	checkSteps = flag.Bool(
+		"gorkin.check-steps",
+		false,
+		"Report registered steps whose patterns can match the same text instead of running features.",
+	)
+	snippets = flag.Bool(
+		"gorkin.snippets",
+		false,
+		"Print snippets for steps which match no step definition instead of running features.",
+	)
+	dryRun = flag.Bool(
+		"gorkin.dry-run",
+		false,
+		"Match every step and check its arguments without calling any step or hook.",
+	)
+	failUnused = flag.Bool(
+		"gorkin.fail-unused",
+		false,
+		"Fail if any registered step matches no line of any feature.",
+	)
+	noColor = flag.Bool(
+		"gorkin.no-color",
+		false,
+		"Don't colour the output of the pretty formatter. Setting NO_COLOR has the same effect.",
+	)
+	color = flag.Bool(
+		"gorkin.color",
+		false,
+		"Colour the output of the pretty formatter even when it isn't written to a terminal.",
+	)
+	// formats holds each -gorkin.format flag.
+	formats formatFlag
+)
+
+func init() {
+	flag.Var(
+		&formats,
+		"gorkin.format",
+		"Report with the named formatter, optionally writing to a file, e.g. text or text:out.txt. May be repeated.",
+	)
+}
+
+// formatFlag collects the values of a flag which may be repeated.
+type formatFlag []string
+
+func (f *formatFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *formatFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
@@ -0,0 +1,273 @@
+package gorkin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formatter is told about each part of a run as it happens so that
+// it can report on it. Events arrive in this order:
+//
+//	SuiteStarted
+//	  FeatureStarted
+//	    LineParsed, for each line of the feature
+//	    ScenarioStarted
+//	      StepStarted, StepFinished, for each step
+//	    ScenarioFinished
+//	  FeatureFinished
+//	SuiteFinished
+//
+// Steps run with RunStep are reported between the StepStarted and
+// StepFinished of the step which ran them. Steps which didn't run
+// because an earlier one failed or was pending, and every step of a
+// dry run, are reported with StatusSkipped.
+// Embed BaseFormatter to implement only some of the methods.
+type Formatter interface {
+	SuiteStarted()
+	FeatureStarted(f *FeatureInfo)
+	LineParsed(l *LineInfo)
+	ScenarioStarted(s *ScenarioInfo)
+	StepStarted(s *StepInfo)
+	StepFinished(s *StepInfo)
+	ScenarioFinished(s *ScenarioInfo)
+	FeatureFinished(f *FeatureInfo)
+	SuiteFinished()
+}
+
+// BaseFormatter implements every method of Formatter by doing
+// nothing.
+type BaseFormatter struct{}
+
+func (BaseFormatter) SuiteStarted()                    {}
+func (BaseFormatter) FeatureStarted(f *FeatureInfo)    {}
+func (BaseFormatter) LineParsed(l *LineInfo)           {}
+func (BaseFormatter) ScenarioStarted(s *ScenarioInfo)  {}
+func (BaseFormatter) StepStarted(s *StepInfo)          {}
+func (BaseFormatter) StepFinished(s *StepInfo)         {}
+func (BaseFormatter) ScenarioFinished(s *ScenarioInfo) {}
+func (BaseFormatter) FeatureFinished(f *FeatureInfo)   {}
+func (BaseFormatter) SuiteFinished()                   {}
+
+// LineInfo describes a line of a feature and the step definition it
+// was matched to.
+type LineInfo struct {
+	// Text is the line without its surrounding space.
+	Text string
+	// Indent is the number of whitespace characters before Text.
+	Indent int
+	// Location is the feature file and number of the line.
+	Location string
+	// Definition is where the function of the step the line matched
+	// is defined. It is empty for lines which aren't steps.
+	Definition string
+	// Err describes why the line couldn't be parsed or matched.
+	Err error
+}
+
+// Attachment is data, such as a screenshot or a response body,
+// attached to a step for formatters to report.
+type Attachment struct {
+	// MediaType describes Data, e.g. "image/png".
+	MediaType string
+	Data      []byte
+}
+
+// Attach attaches data of the given media type to the step. Steps and
+// hooks can attach data by accepting a *StepInfo.
+func (s *StepInfo) Attach(mediaType string, data []byte) {
+	s.Attachments = append(s.Attachments, &Attachment{MediaType: mediaType, Data: data})
+}
+
+// formatterConstructors holds the formatters which can be chosen with
+// -gorkin.format, by name. The formatters other than text add
+// themselves from the files which define them.
+var formatterConstructors = map[string]func(w io.Writer) Formatter{
+	"text": func(w io.Writer) Formatter { return &textFormatter{w: w} },
+}
+
+// RegisterFormatter makes a formatter available to -gorkin.format as
+// name. newFormatter is given the writer the formatter should write
+// to: standard output or the file named in the flag. It panics if
+// name is already registered.
+func RegisterFormatter(name string, newFormatter func(w io.Writer) Formatter) {
+	if _, ok := formatterConstructors[name]; ok || strings.Contains(name, ":") {
+		panic(fmt.Errorf("cannot register the formatter %q", name))
+	}
+	formatterConstructors[name] = newFormatter
+}
+
+// openFormatters creates the formatters chosen with -gorkin.format,
+// or the text formatter if none were chosen. The returned function
+// closes any files the formatters write to.
+func openFormatters() (Formatter, func()) {
+	specs := []string(formats)
+	if len(specs) == 0 {
+		specs = []string{"text"}
+	}
+
+	var all multiFormatter
+	var files []*os.File
+	for _, spec := range specs {
+		name, path := spec, ""
+		if colon := strings.Index(spec, ":"); colon >= 0 {
+			name, path = spec[:colon], spec[colon+1:]
+		}
+
+		newFormatter, ok := formatterConstructors[name]
+		if !ok {
+			log.Fatalf("unknown formatter %q; choose from %s", name, strings.Join(formatterNames(), ", "))
+		}
+
+		var w io.Writer = os.Stdout
+		if path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				log.Fatalf("could not create output file: %v", err)
+			}
+			files = append(files, f)
+			w = f
+		}
+		all = append(all, newFormatter(w))
+	}
+
+	return all, func() {
+		for _, f := range files {
+			if err := f.Close(); err != nil {
+				log.Printf("could not close output file: %v", err)
+			}
+		}
+	}
+}
+
+// formatterNames returns the names of the registered formatters in
+// order.
+func formatterNames() []string {
+	var names []string
+	for name := range formatterConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// multiFormatter passes each event on to every one of its formatters.
+type multiFormatter []Formatter
+
+func (m multiFormatter) SuiteStarted() {
+	for _, f := range m {
+		f.SuiteStarted()
+	}
+}
+
+func (m multiFormatter) FeatureStarted(info *FeatureInfo) {
+	for _, f := range m {
+		f.FeatureStarted(info)
+	}
+}
+
+func (m multiFormatter) LineParsed(l *LineInfo) {
+	for _, f := range m {
+		f.LineParsed(l)
+	}
+}
+
+func (m multiFormatter) ScenarioStarted(s *ScenarioInfo) {
+	for _, f := range m {
+		f.ScenarioStarted(s)
+	}
+}
+
+func (m multiFormatter) StepStarted(s *StepInfo) {
+	for _, f := range m {
+		f.StepStarted(s)
+	}
+}
+
+func (m multiFormatter) StepFinished(s *StepInfo) {
+	for _, f := range m {
+		f.StepFinished(s)
+	}
+}
+
+func (m multiFormatter) ScenarioFinished(s *ScenarioInfo) {
+	for _, f := range m {
+		f.ScenarioFinished(s)
+	}
+}
+
+func (m multiFormatter) FeatureFinished(info *FeatureInfo) {
+	for _, f := range m {
+		f.FeatureFinished(info)
+	}
+}
+
+func (m multiFormatter) SuiteFinished() {
+	for _, f := range m {
+		f.SuiteFinished()
+	}
+}
+
+// textFormatter names each feature and scenario as it's run, and
+// lists pending steps. The lines of a feature which couldn't be parsed
+// or matched are listed along with the steps which the other lines
+// matched.
+type textFormatter struct {
+	BaseFormatter
+	w io.Writer
+	// lines holds the current feature's lines until it's known
+	// whether any failed.
+	lines  bytes.Buffer
+	failed bool
+}
+
+func (f *textFormatter) FeatureStarted(info *FeatureInfo) {
+	fmt.Fprintf(f.w, "Processing: \"%s\".\n", filepath.Base(info.Path))
+	f.lines.Reset()
+	f.failed = false
+}
+
+func (f *textFormatter) LineParsed(l *LineInfo) {
+	fmt.Fprint(&f.lines, strings.Repeat("  ", l.Indent)+l.Text+"\t")
+	if l.Err != nil {
+		fmt.Fprintf(&f.lines, "✗ %s", l.Err)
+		f.failed = true
+	} else if l.Definition != "" {
+		fmt.Fprintf(&f.lines, "✓ %s", l.Definition)
+	}
+	fmt.Fprint(&f.lines, "\n")
+}
+
+func (f *textFormatter) ScenarioStarted(s *ScenarioInfo) {
+	f.flushLines()
+	if s.Location != "" {
+		fmt.Fprintf(f.w, "Scenario: %s\n", s.Name)
+	}
+}
+
+func (f *textFormatter) StepFinished(s *StepInfo) {
+	if s.Status == StatusPending {
+		fmt.Fprintf(f.w, "%s: %s: pending\n", s.Location, s.Text)
+	}
+}
+
+func (f *textFormatter) FeatureFinished(info *FeatureInfo) {
+	f.flushLines()
+}
+
+// flushLines lists the current feature's lines if any of them failed.
+func (f *textFormatter) flushLines() {
+	if f.failed {
+		w := tabwriter.NewWriter(f.w, 0, 1, 2, ' ', 0)
+		f.lines.WriteTo(w)
+		w.Flush()
+	}
+	f.lines.Reset()
+	f.failed = false
+}
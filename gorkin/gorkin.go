@@ -2,6 +2,7 @@ package gorkin
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,61 +11,151 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
-	"strconv"
+	"runtime/debug"
 	"strings"
 	"testing"
-	"text/tabwriter"
+	"time"
 
 	. "github.com/kat-co/vala"
 )
 
 var (
-	Debug = log.New(ioutil.Discard, "DEBUG: ", log.Llongfile)
+	Debug   = log.New(ioutil.Discard, "DEBUG: ", log.Llongfile)
 	Warning = log.New(ioutil.Discard, "WARNING: ", log.Llongfile)
 )
 
+// RunFeatureTests runs all feature files found in the features
+// directory against the steps registered with DefaultRegistry.
 func RunFeatureTests(t *testing.T, stepIsolater interface{}) {
+	DefaultRegistry.RunFeatureTests(t, stepIsolater)
+}
+
+func runFeatureTests(reg *Registry, t *testing.T, stepIsolater interface{}) {
 
 	if Debug == nil {
 		panic("Debug is nil somehow...")
 	}
-	
+
+	if *checkSteps {
+		for _, conflict := range reg.Check() {
+			t.Error(conflict)
+		}
+		return
+	}
+
 	stepType := reflect.PtrTo(reflect.TypeOf(stepIsolater)).Elem()
-	// Steps should be in the steps folder under features
-	files, err := ioutil.ReadDir("../")
-	if err != nil {
-		log.Fatalf("could not read features directory: %v", err)
+	paths := featureFiles(reg.featuresDir())
+	reg.used = nil
+
+	format, closeFormat := openFormatters()
+	defer closeFormat()
+	reg.format = format
+	reg.format.SuiteStarted()
+	defer reg.format.SuiteFinished()
+
+	if *snippets {
+		reportSnippets(reg, t, paths, stepType)
+		return
 	}
 
-	numFeaturesProcessed := 0
-	for _, f := range files {
-		if filepath.Ext(f.Name()) != ".feature" {
+	if *dryRun {
+		dryRunFeatures(reg, t, paths, stepType)
+		return
+	}
+
+	sc := &scope{T: t, StepType: stepType, Registry: reg, Providers: reg.providers}
+	defer func() { reportHookErrors(t, reg.runHooks(afterSuite, sc)) }()
+	if reportHookErrors(t, reg.runHooks(beforeSuite, sc)) {
+		return
+	}
+
+	for _, featurePath := range paths {
+		f, err := readFeature(reg, featurePath)
+		if err != nil {
+			// The remaining features are still read and run, as they
+			// are in a dry run, so that the steps they use aren't
+			// reported as unused.
+			reportFeatureError(t, err, stepType)
+			reg.format.FeatureFinished(f.Info)
 			continue
 		}
-		numFeaturesProcessed++
-
-		fmt.Println(os.Getwd())
-		fmt.Printf("Processing: \"%s\".\n", f.Name())
-		feat, err := ioutil.ReadFile(filepath.Join("..", f.Name()))
+		err = runFeature(reg, f, t, stepType)
+		reg.format.FeatureFinished(f.Info)
 		if err != nil {
-			log.Fatalf("could not read feature file: %v", err)
+			// Returning rather than exiting lets the AfterSuite hooks
+			// and the formatters finish. The features which weren't
+			// read may use any step, so none is reported as unused.
+			t.Error(err)
+			return
 		}
+	}
 
-		if f, err := handleFeature(bufio.NewReader(strings.NewReader(string(feat)))); err != nil {
-			t.Errorf("\n\n%v", err)
-			return
-		} else if _, err := run(f.Runners, t, stepType, f.Background...); err != nil {
-			log.Fatalf("%v", err)
+	reg.reportUnusedSteps(t)
+}
+
+// reportFeatureError fails t with err, which was returned by
+// handleFeature. Undefined steps are reported along with the steps
+// which may have been meant and snippets to implement them.
+func reportFeatureError(t *testing.T, err error, stepType reflect.Type) {
+	if undefined, ok := err.(*undefinedStepsError); ok {
+		t.Errorf("\n\n%v\n%s\nYou can implement them with these snippets:\n\n%s",
+			err,
+			suggestionsMessage(undefined.Steps),
+			snippetsFor(undefined.Steps, stepType),
+		)
+		return
+	}
+	t.Errorf("\n\n%v", err)
+}
+
+// featureFiles returns the paths of the feature files in dir.
+func featureFiles(dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.Fatalf("could not read features directory: %v", err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		if filepath.Ext(f.Name()) == ".feature" {
+			paths = append(paths, filepath.Join(dir, f.Name()))
 		}
 	}
 
-	if numFeaturesProcessed <= 0 {
+	if len(paths) <= 0 {
 		log.Fatal("No feature files found.")
 	}
+	return paths
+}
+
+// readFeature reads and parses the feature file at path, and tells
+// the registry's formatter about it. The feature is returned even if
+// it couldn't be parsed, so that its FeatureFinished can be reported.
+func readFeature(reg *Registry, path string) (*feature, error) {
+	feat, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("could not read feature file: %v", err)
+	}
+
+	ftr, err := handleFeature(reg, path, bufio.NewReader(strings.NewReader(string(feat))))
+	if ftr == nil {
+		ftr = &feature{Path: path, Info: &FeatureInfo{Path: path}}
+	}
+	reg.format.FeatureStarted(ftr.Info)
+	for _, l := range ftr.Lines {
+		reg.format.LineParsed(l)
+	}
+	return ftr, err
 }
 
-func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
-	BeginValidation().Validate(IsNotNil(fReader, "fReader")).CheckAndPanic()
+// handleFeature parses the feature read from fReader and matches each
+// of its steps against reg. path is used to describe where steps come
+// from.
+func handleFeature(reg *Registry, path string, fReader *bufio.Reader) (ftr *feature, err error) {
+	BeginValidation().Validate(
+		IsNotNil(reg, "reg"),
+		IsNotNil(fReader, "fReader"),
+	).CheckAndPanic()
 
 	// TODO(kate): Stupid dumb parsing just to get things moving.
 
@@ -80,12 +171,22 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 		BackgroundMode  mode = "Background"
 	)
 
-	ftr = &feature{}
-	w := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	ftr = &feature{Path: path}
 	modeStack := []mode{DeclarationMode}
 	atLeastOneMissingRunner := false
 	scenarioIndentation := 0
 	pythonString := ""
+	var doc *docString
+	// Doc strings are delimited by either """ or ```.
+	docDelimiter := ""
+	lineNum := 0
+	language := "en"
+	// Tags apply to the next Feature or Scenario declaration.
+	var tags []string
+	// Steps which match no step definition are collected so that
+	// snippets can be offered for them.
+	var undefined []*undefinedStep
+	ambiguous := 0
 
 	endOfBackgroundBlock := func(stateStack []mode) ([]*runnerAndArgs, bool) {
 		backgroundRunners := make([]*runnerAndArgs, 0)
@@ -123,15 +224,23 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 
 	for {
 		var rawLine string
-		if rawLine, err = fReader.ReadString('\n'); err == io.EOF {
+		if rawLine, err = fReader.ReadString('\n'); err == io.EOF && rawLine == "" {
 			break
-		} else if err != nil {
+		} else if err != nil && err != io.EOF {
 			return nil, err
 		}
+		// The last line of a file need not end in a newline.
+		err = nil
+		lineNum++
+		// The Feature line moves lineNum past its description, so the
+		// line's location is taken first.
+		location := fmt.Sprintf("%s:%d", path, lineNum)
 
 		line := strings.TrimSpace(rawLine)
 		indentCount := len(rawLine) - len(line)
-		andSpecified := strings.HasPrefix(line, "And")
+		andSpecified := strings.HasPrefix(line, "And ") ||
+			strings.HasPrefix(line, "But ") ||
+			strings.HasPrefix(line, "* ")
 		andModeFor := func(mode mode) bool {
 			return andSpecified && modeStack[0] == mode
 		}
@@ -145,19 +254,21 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 		switch {
 		default:
 			return nil, fmt.Errorf("Unknown line type: %s", strings.Split(line, " ")[0])
-		case strings.HasPrefix(line, `"""`):
+		case modeStack[0] != PythonString && (strings.HasPrefix(line, `"""`) || strings.HasPrefix(line, "```")),
+			modeStack[0] == PythonString && strings.HasPrefix(line, docDelimiter):
 
 			if modeStack[0] == PythonString {
 				// Pop PythonString if it has ended
 				modeStack = modeStack[1:]
 
 				if len(ftr.Runners) > 0 {
-					args := ftr.Runners[len(ftr.Runners)-1].Args
+					last := ftr.Runners[len(ftr.Runners)-1]
 					if len(pythonString) != 0 {
 						pythonString = pythonString[1:]
 					}
-					args = append(args, fmt.Sprintf(pythonString))
-					ftr.Runners[len(ftr.Runners)-1].Args = args
+					last.Args = append(last.Args, pythonString)
+					doc.Content = pythonString
+					last.DocString = doc
 				}
 
 				pythonString = ""
@@ -165,6 +276,15 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 				// Push PythonString
 				scenarioIndentation = indentCount
 				modeStack = append([]mode{PythonString}, modeStack...)
+				docDelimiter = line[:3]
+				if n := len(undefined); n > 0 && undefined[n-1].LineNum == lineNum-1 {
+					undefined[n-1].DocString = true
+				}
+				doc = &docString{
+					MediaType: strings.TrimSpace(line[3:]),
+					Path:      path,
+					Line:      lineNum + 1,
+				}
 			}
 		case modeStack[0] == PythonString && len(ftr.Runners) > 0:
 			// The python string we're building up is meant to be an
@@ -176,6 +296,17 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 			if len(rawLine) > 1 {
 				pythonString += rawLine[scenarioIndentation-1 : len(rawLine)-1]
 			}
+		case strings.HasPrefix(line, "@"):
+			var lineTags []string
+			if lineTags, err = parseTags(line); err == nil {
+				tags = append(tags, lineTags...)
+			}
+		case strings.HasPrefix(line, "#"):
+			// Comments may declare the language of the feature.
+			comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			if strings.HasPrefix(comment, "language:") {
+				language = strings.TrimSpace(strings.TrimPrefix(comment, "language:"))
+			}
 		case line == "":
 			if len(ftr.Background) <= 0 {
 				if backgroundRunners, ok := endOfBackgroundBlock(modeStack); ok {
@@ -185,7 +316,14 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 			break
 		case strings.HasPrefix(line, "Feature:"):
 			modeStack = append([]mode{DeclarationMode}, modeStack...)
-			_, err = ParseFeature(line, fReader)
+			var parsed *Feature
+			ftr.Tags, tags = tags, nil
+			if parsed, err = ParseFeature(line, fReader); err == nil {
+				ftr.Name = parsed.description
+				// ParseFeature consumes the description and the
+				// blank line which ends it.
+				lineNum += strings.Count(parsed.caseStatement, "\n") + 1
+			}
 		case strings.HasPrefix(line, "Background:"):
 
 			if ftr.Background != nil {
@@ -203,10 +341,12 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 			}
 
 			modeStack = append([]mode{DeclarationMode}, modeStack...)
-			ftr.Runners = append(ftr.Runners, &runnerAndArgs{Step: line})
+			runner = &runnerAndArgs{Step: line, Tags: tags}
+			tags = nil
+			ftr.Runners = append(ftr.Runners, runner)
 		case strings.HasPrefix(line, "Given") || andModeFor(GivenMode):
 			modeStack = append([]mode{GivenMode}, modeStack...)
-			if runner, err = ParseGiven(line, fReader); err != nil {
+			if runner, err = reg.parseStep(line, fReader); err != nil {
 				atLeastOneMissingRunner = true
 			} else {
 				lineComment = runner.StepInfo()
@@ -214,7 +354,7 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 			}
 		case strings.HasPrefix(line, "When") || andModeFor(WhenMode):
 			modeStack = append([]mode{WhenMode}, modeStack...)
-			if runner, err = ParseWhen(line, fReader); err != nil {
+			if runner, err = reg.parseStep(line, fReader); err != nil {
 				atLeastOneMissingRunner = true
 			} else {
 				lineComment = runner.StepInfo()
@@ -222,7 +362,7 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 			}
 		case strings.HasPrefix(line, "Then") || andModeFor(ThenMode):
 			modeStack = append([]mode{ThenMode}, modeStack...)
-			runner, err = ParseThen(line, fReader)
+			runner, err = reg.parseStep(line, fReader)
 			if err != nil {
 				atLeastOneMissingRunner = true
 				break
@@ -233,24 +373,47 @@ func handleFeature(fReader *bufio.Reader) (ftr *feature, err error) {
 			modeStack = append([]mode{Example}, modeStack...)
 			err = fmt.Errorf("Not yet supported")
 		case andSpecified:
-			fmt.Errorf("for line: %s", line)
 			return nil, fmt.Errorf("and clauses may only follow a Given, When, or Then clause.")
 		}
 
-		// Let user know status of line
-		fmt.Fprintf(w, strings.Repeat("  ", indentCount)+line+"\t")
-		if err != nil {
-			fmt.Fprintf(w, "✗ %s", err)
-		} else if lineComment != "" {
-			fmt.Fprintf(w, "✓ %s", lineComment)
+		if _, ok := err.(*ambiguousStepError); ok {
+			ambiguous++
+		} else if err == errNoMatchingRunner {
+			undefined = append(undefined, &undefinedStep{
+				Line:        line,
+				LineNum:     lineNum,
+				Location:    location,
+				Suggestions: reg.suggestions(stepText(line)),
+			})
+		}
+
+		if runner != nil {
+			runner.Line = line
+			runner.Location = location
+			runner.Language = language
 		}
 
-		fmt.Fprintf(w, "\n")
+		// Let user know status of line
+		ftr.Lines = append(ftr.Lines, &LineInfo{
+			Text:       line,
+			Indent:     indentCount,
+			Location:   location,
+			Definition: lineComment,
+			Err:        err,
+		})
 	}
 
+	ftr.Info = &FeatureInfo{Name: ftr.Name, Path: ftr.Path, Tags: ftr.Tags}
 	if atLeastOneMissingRunner {
-		w.Flush()
-		return nil, fmt.Errorf("Please implement the missing runners.")
+		if len(undefined) > 0 {
+			return ftr, &undefinedStepsError{Steps: undefined}
+		} else if ambiguous > 0 {
+			return ftr, fmt.Errorf(
+				"%d steps match more than one runner. Make their patterns more specific or set Options.MatchPolicy; gorkin steps check lists every overlap.",
+				ambiguous,
+			)
+		}
+		return ftr, fmt.Errorf("Please implement the missing runners.")
 	}
 
 	Debug.Printf("Feature has %d background steps, and %d other steps",
@@ -273,6 +436,22 @@ func debugSteps(steps ...*runnerAndArgs) {
 // feature contains everything needed to execute tests against a
 // feature file.
 type feature struct {
+	// Name is the feature's description.
+	Name string
+
+	// Path is the feature's file.
+	Path string
+
+	// Tags are the tags declared above the feature.
+	Tags []string
+
+	// Info describes the feature to hooks and formatters.
+	Info *FeatureInfo
+
+	// Lines describe each line of the feature and the step it
+	// matched.
+	Lines []*LineInfo
+
 	// Background represents a defined background clause for the
 	// feature. This will be executed before each Scenario.
 	Background []*runnerAndArgs
@@ -288,26 +467,86 @@ type runnerAndArgs struct {
 	// Args contains the string representations of the arguments to
 	// the runner.
 	Args []string
+	// Params holds the parameter type of each of Args, or nil for
+	// arguments which were matched by a plain regex group.
+	Params []*parameterType
+	// Offsets holds the byte offset of each group within the step's
+	// text, or -1 for groups which took no part in the match.
+	Offsets []int
+	// Matched records whether the group for each of Args took part in
+	// the match. Optional groups which didn't are captured as "".
+	Matched []bool
+	// Names holds the name of each group, or "" for unnamed groups.
+	// Args beyond the groups, such as doc strings, have no name.
+	Names []string
 	// Step is the line in the feature file which was matched.
 	Step string
+	// Line is the text of the step's line in the feature file.
+	Line string
+	// Location is the feature file and line number of the step.
+	Location string
+	// Language is the language the step's feature is written in.
+	Language string
+	// DocString is the doc string which follows the step, if any. Its
+	// content is also the last of Args.
+	DocString *docString
+	// Tags are the tags declared above a scenario. Only scenario
+	// declarations have tags.
+	Tags []string
+}
+
+// hasNamedGroups reports whether any of the step's groups are named.
+func (r *runnerAndArgs) hasNamedGroups() bool {
+	for _, name := range r.Names {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// matched reports whether the group at groupIdx took part in the
+// match. Arguments which didn't come from a group, such as doc
+// strings, are always considered matched.
+func (r *runnerAndArgs) matched(groupIdx int) bool {
+	return groupIdx >= len(r.Matched) || r.Matched[groupIdx]
+}
+
+// argError describes a failure to convert the argument captured by
+// the group at groupIdx.
+func (r *runnerAndArgs) argError(groupIdx int, err error) error {
+	return fmt.Errorf("%s: %s: group %d (%q): %v",
+		r.Location,
+		r.Line,
+		groupIdx+1,
+		r.Args[groupIdx],
+		err,
+	)
 }
 
 func (r *runnerAndArgs) StepInfo() string {
-	fp := reflect.ValueOf(r.Runner).Pointer()
-	f, l := runtime.FuncForPC(fp).FileLine(fp)
+	return funcLocation(r.Runner)
+}
+
+// funcLocation returns the file, relative to the working directory,
+// and line at which f was defined.
+func funcLocation(f runner) string {
+	fp := reflect.ValueOf(f).Pointer()
+	file, l := runtime.FuncForPC(fp).FileLine(fp)
 
 	if wd, err := os.Getwd(); err != nil {
 		panic(err)
-	} else if relPath, err := filepath.Rel(wd, f); err != nil {
-		panic(err)
+	} else if relPath, err := filepath.Rel(wd, file); err != nil {
+		// Generated wrappers, such as those of methods with value
+		// receivers, have no real file.
+		return fmt.Sprintf("%s:%d", file, l)
 	} else {
 		return fmt.Sprintf("%s:%d", relPath, l)
 	}
 }
 
 func accountForParamAndArgDiffFn(
-	forTest *testing.T,
-	forStep reflect.Type,
+	sc *scope,
 ) func(string, int, int, func(int) reflect.Type) error {
 	return func(step string, numStepParams, numRegexGroups int, paramTypeFn func(int) reflect.Type) error {
 		numOff := numStepParams - numRegexGroups
@@ -316,11 +555,8 @@ func accountForParamAndArgDiffFn(
 		// the difference by types we know how to create.
 		numGorkinGeneratableTypes := 0
 		for pn := 0; pn < numStepParams; pn++ {
-			switch paramTypeFn(pn) {
-			case reflect.TypeOf(forTest), forStep:
+			if sc.canInject(paramTypeFn(pn)) {
 				numGorkinGeneratableTypes++
-				// default:
-				// 	t.Logf("Failed type: %v", rt.In(pn))
 			}
 		}
 
@@ -337,92 +573,366 @@ func accountForParamAndArgDiffFn(
 	}
 }
 
-func run(runners []*runnerAndArgs, t *testing.T, stepType reflect.Type, backgroundSteps ...*runnerAndArgs) (reflect.Value, error) {
+// runFeature runs each scenario of ftr, surrounded by the feature
+// hooks.
+func runFeature(reg *Registry, ftr *feature, t *testing.T, stepType reflect.Type) (err error) {
+	featureInfo := ftr.Info
+	sc := &scope{T: t, StepType: stepType, Feature: featureInfo, Registry: reg, Providers: reg.providers}
+	defer func() { reportHookErrors(t, reg.runHooks(afterFeature, sc)) }()
+	if reportHookErrors(t, reg.runHooks(beforeFeature, sc)) {
+		return nil
+	}
+	return run(reg, featureInfo, ftr.Runners, t, stepType, ftr.Background...)
+}
+
+func run(reg *Registry, featureInfo *FeatureInfo, runners []*runnerAndArgs, t *testing.T, stepType reflect.Type, backgroundSteps ...*runnerAndArgs) error {
 
 	Debug.Printf("Running %d steps with %d background steps.",
 		len(runners),
 		len(backgroundSteps),
 	)
 
-	tType := reflect.TypeOf(t)
-	stepVal := reflect.New(stepType.Elem())
-	accountForParamAndArgDiff := accountForParamAndArgDiffFn(t, stepType)
+	for _, scenario := range splitScenarios(runners) {
+		if err := runScenario(reg, featureInfo, scenario, t, stepType, backgroundSteps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// splitScenarios splits the runners of a feature into scenarios. Any
+// steps before the first scenario are a scenario of their own.
+func splitScenarios(runners []*runnerAndArgs) [][]*runnerAndArgs {
+	var scenarios [][]*runnerAndArgs
 	for _, r := range runners {
+		if strings.HasPrefix(r.Step, "Scenario") || len(scenarios) == 0 {
+			scenarios = append(scenarios, nil)
+		}
+		scenarios[len(scenarios)-1] = append(scenarios[len(scenarios)-1], r)
+	}
+	return scenarios
+}
 
-		Debug.Printf(`Processing step: "%v"`, r.Step)
+// newScenarioInfo describes scenario, whose first line is its
+// declaration if it has one, and returns the steps to run for it: the
+// background steps followed by its own.
+func newScenarioInfo(featureInfo *FeatureInfo, scenario []*runnerAndArgs, backgroundSteps []*runnerAndArgs) (*ScenarioInfo, []*runnerAndArgs) {
 
-		if strings.HasPrefix(r.Step, "Scenario") {
-			fmt.Println(r.Step)
-			// For each scenario, re-run the background clause.
-			Debug.Println("== BACKGROUND ==")
-			if newContext, err := run(backgroundSteps, t, stepType); err != nil {
-				return stepVal, err
-			} else {
-				Debug.Println("== END BACKGROUND ==")
-				stepVal = newContext
-			}
-			continue
-		} else if strings.HasPrefix(r.Step, "Background") {
-			continue
+	// Scenarios inherit the tags of their feature.
+	scenarioInfo := &ScenarioInfo{
+		Feature: featureInfo,
+		Tags:    append([]string{}, featureInfo.Tags...),
+	}
+	if declaration := scenario[0]; strings.HasPrefix(declaration.Step, "Scenario") {
+		scenarioInfo.Name = declarationName(declaration.Step)
+		scenarioInfo.Location = declaration.Location
+		scenarioInfo.Tags = append(scenarioInfo.Tags, declaration.Tags...)
+		scenario = scenario[1:]
+	}
+
+	// For each scenario, re-run the background clause.
+	var steps []*runnerAndArgs
+	for _, r := range append(append([]*runnerAndArgs{}, backgroundSteps...), scenario...) {
+		if r.Runner != nil {
+			steps = append(steps, r)
+		}
+	}
+	return scenarioInfo, steps
+}
+
+// runScenario runs the steps of a scenario, preceded by the
+// background steps, in a subtest of its own. The first line of
+// scenario is the scenario's declaration, if it has one. A step which
+// fails skips the rest of its scenario.
+func runScenario(reg *Registry, featureInfo *FeatureInfo, scenario []*runnerAndArgs, t *testing.T, stepType reflect.Type, backgroundSteps []*runnerAndArgs) error {
+
+	scenarioInfo, steps := newScenarioInfo(featureInfo, scenario, backgroundSteps)
+
+	var runErr error
+	t.Run(scenarioInfo.Name, func(t *testing.T) {
+		sc := &scope{
+			T:         t,
+			StepType:  stepType,
+			StepVal:   reflect.New(stepType.Elem()),
+			Feature:   featureInfo,
+			Scenario:  scenarioInfo,
+			Registry:  reg,
+			Providers: reg.providers,
+			Provided:  make(map[reflect.Type]reflect.Value),
 		}
 
-		rt := reflect.TypeOf(r.Runner)
-		if rt.Kind() != reflect.Func {
-			return stepVal, fmt.Errorf("Steps must be functions, not %v", rt)
+		// This cleanup is registered first so that it runs after the
+		// isolation value's.
+		reg.format.ScenarioStarted(scenarioInfo)
+		t.Cleanup(func() {
+			if t.Failed() {
+				scenarioInfo.Status = StatusFailed
+			}
+			reg.format.ScenarioFinished(scenarioInfo)
+		})
+
+		ran := 0
+		defer func() {
+			if t.Failed() {
+				scenarioInfo.Status = StatusFailed
+			}
+			// The steps after a failure are reported as skipped.
+			for _, r := range steps[ran:] {
+				skipped := newStepInfo(r, sc)
+				skipped.Status = StatusSkipped
+				reg.format.StepStarted(skipped)
+				reg.format.StepFinished(skipped)
+			}
+			reportHookErrors(t, reg.runHooks(afterScenario, sc))
+		}()
+		if err := sc.setUpIsolation(); err != nil {
+			t.Error(err)
+			return
+		}
+		if reportHookErrors(t, reg.runHooks(beforeScenario, sc)) {
+			return
 		}
 
-		numRegexArgs := len(r.Args)
-		numStepArgs := rt.NumIn()
-		if numStepArgs > numRegexArgs {
-			err := accountForParamAndArgDiff(r.Step, numStepArgs, numRegexArgs, rt.In)
+		for _, r := range steps {
+			ran++
+			Debug.Printf(`Processing step: "%v"`, r.Step)
+			stepInfo, err := runStepWithHooks(reg, r, sc)
 			if err != nil {
-				Warning.Println(err)
+				runErr = err
+				return
+			} else if status := stepInfo.Status; status == StatusPending {
+				// Pending scenarios are skipped rather than failed.
+				scenarioInfo.Status = StatusPending
+				t.Skipf("%s: %s: pending", r.Location, r.Line)
+			} else if status != StatusPassed {
+				return
 			}
 		}
+	})
+	return runErr
+}
 
-		// Build up the arguments
-		var args []reflect.Value
-		for stepArgIdx, regexGroupIdx := 0, 0; stepArgIdx < numStepArgs; stepArgIdx++ {
-
-			switch rt.In(stepArgIdx) {
-			default:
-				return stepVal, fmt.Errorf(
-					`Cannot handle steps which accept arguments of type "%v" at this time.`,
-					rt.In(stepArgIdx),
-				)
-			case tType:
-				args = append(args, reflect.ValueOf(t))
-			case stepType:
-				args = append(args, stepVal)
-			case reflect.TypeOf(true):
-				b := false
-				if r.Args[regexGroupIdx] != "" {
-					b = true
-				}
-				args = append(args, reflect.ValueOf(b))
-				regexGroupIdx++
-			case reflect.TypeOf(""):
-				if len(r.Args) > regexGroupIdx {
-					args = append(args, reflect.ValueOf(r.Args[regexGroupIdx]))
-				} else {
-					Warning.Println("Assuming a doc string will be passed in.")
-					args = append(args, reflect.ValueOf(""))
-				}
-				regexGroupIdx++
-			case reflect.TypeOf(0):
-				i, err := strconv.Atoi(r.Args[regexGroupIdx])
-				if err != nil {
-					return stepVal, err
-				}
-				args = append(args, reflect.ValueOf(i))
-				regexGroupIdx++
+// runStepWithHooks runs r surrounded by the step hooks and returns its
+// description. Failures are reported to sc.T and recorded in the
+// description's Status and Err; only errors which should stop every
+// scenario from running are returned.
+func runStepWithHooks(reg *Registry, r *runnerAndArgs, sc *scope) (_ *StepInfo, err error) {
+	stepInfo := newStepInfo(r, sc)
+	stepInfo.Status = StatusFailed
+	stepSc := *sc
+	stepSc.Step = stepInfo
+	stepSc.Language = r.Language
+
+	reg.format.StepStarted(stepInfo)
+	start := time.Now()
+	alreadyFailed := sc.T.Failed()
+	defer func() {
+		// Steps which call t.FailNow exit through here too.
+		stepInfo.Duration = time.Since(start)
+		if stepInfo.Status == StatusFailed && stepInfo.Err == nil && sc.T.Failed() == alreadyFailed {
+			stepInfo.Status = StatusPassed
+		}
+		if reportHookErrors(sc.T, reg.runHooks(afterStep, &stepSc)) {
+			stepInfo.Status = StatusFailed
+		}
+		reg.format.StepFinished(stepInfo)
+	}()
+
+	if reportHookErrors(sc.T, reg.runHooks(beforeStep, &stepSc)) {
+		stepInfo.Status = StatusSkipped
+		return stepInfo, nil
+	}
+
+	if err = runStep(reg, r, &stepSc); err == ErrPending {
+		// A step which failed its *testing.T before saying it was
+		// pending has still failed.
+		if sc.T.Failed() == alreadyFailed {
+			stepInfo.Status = StatusPending
+		}
+		err = nil
+	} else if failure, ok := err.(*stepFailure); ok {
+		sc.T.Error(failure)
+		stepInfo.Err = failure.error
+		err = nil
+	} else if reported, ok := err.(*reportedError); ok {
+		stepInfo.Err = reported.error
+		err = nil
+	}
+	// The deferred function above decides the status.
+	return stepInfo, err
+}
+
+// newStepInfo describes r, a step being run in the scope sc.
+func newStepInfo(r *runnerAndArgs, sc *scope) *StepInfo {
+	info := &StepInfo{
+		Scenario:   sc.Scenario,
+		Parent:     sc.Step,
+		Text:       r.Line,
+		Location:   r.Location,
+		Pattern:    r.Step,
+		Definition: r.StepInfo(),
+	}
+
+	// Offsets are within the step's text, which follows its keyword.
+	textStart := strings.Index(r.Line, stepText(r.Line))
+	for i, offset := range r.Offsets {
+		if offset >= 0 && textStart >= 0 {
+			info.Args = append(info.Args, &StepArg{Value: r.Args[i], Offset: textStart + offset})
+		}
+	}
+	return info
+}
+
+// reportHookErrors fails t with each of errs, and reports whether
+// there were any.
+func reportHookErrors(t *testing.T, errs []error) bool {
+	for _, err := range errs {
+		t.Error(err)
+	}
+	return len(errs) > 0
+}
+
+// declarationName returns the description following the colon of a
+// declaration such as "Scenario: ...".
+func declarationName(line string) string {
+	if colon := strings.Index(line, ":"); colon >= 0 {
+		return strings.TrimSpace(line[colon+1:])
+	}
+	return line
+}
+
+// stepFailure is an error which fails the step it occurred in. Unlike
+// other errors, it doesn't stop the remaining scenarios from running.
+type stepFailure struct {
+	error
+}
+
+// runStep builds the arguments for r's runner and calls it.
+func runStep(reg *Registry, r *runnerAndArgs, sc *scope) (err error) {
+
+	// A step which panics, or whose arguments can't be built, fails
+	// rather than taking every other scenario down with it.
+	defer func() {
+		if p := recover(); p != nil {
+			if pErr, ok := p.(error); ok && errors.Is(pErr, ErrPending) {
+				err = ErrPending
+				return
+			}
+			err = &stepFailure{fmt.Errorf("%s: %s: panic: %v\n%s", r.Location, r.Line, p, debug.Stack())}
+		}
+	}()
+
+	args, err := stepArgs(reg, r, sc)
+	if err != nil {
+		return err
+	}
+
+	var out []reflect.Value
+	if reflect.TypeOf(r.Runner).IsVariadic() {
+		out = reflect.ValueOf(r.Runner).CallSlice(args)
+	} else {
+		out = reflect.ValueOf(r.Runner).Call(args)
+	}
+
+	// A step may return an error as its last result to fail.
+	if n := len(out); n > 0 && out[n-1].Type() == errorType && !out[n-1].IsNil() {
+		stepErr := out[n-1].Interface().(error)
+		var reported *reportedError
+		if errors.Is(stepErr, ErrPending) {
+			return ErrPending
+		} else if errors.As(stepErr, &reported) {
+			return reported
+		}
+		return &stepFailure{fmt.Errorf("%s: %s: %v", r.Location, r.Line, stepErr)}
+	}
+	return nil
+}
+
+// stepArgs builds the arguments for r's runner from its groups, doc
+// string and the values sc can inject.
+func stepArgs(reg *Registry, r *runnerAndArgs, sc *scope) ([]reflect.Value, error) {
+
+	accountForParamAndArgDiff := accountForParamAndArgDiffFn(sc)
+
+	rt := reflect.TypeOf(r.Runner)
+	if rt.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Steps must be functions, not %v", rt)
+	}
+
+	numRegexArgs := len(r.Args)
+	numStepArgs := rt.NumIn()
+	if numStepArgs > numRegexArgs {
+		err := accountForParamAndArgDiff(r.Step, numStepArgs, numRegexArgs, rt.In)
+		if err != nil {
+			Warning.Println(err)
+		}
+	}
+
+	// Build up the arguments
+	var args []reflect.Value
+	for stepArgIdx, regexGroupIdx := 0, 0; stepArgIdx < numStepArgs; stepArgIdx++ {
+
+		paramType := rt.In(stepArgIdx)
+		if arg, ok, err := sc.inject(paramType); err != nil {
+			return nil, &stepFailure{fmt.Errorf("%s: %s: %v", r.Location, r.Line, err)}
+		} else if ok {
+			args = append(args, arg)
+			continue
+		}
+
+		switch {
+		case regexGroupIdx == 0 && r.hasNamedGroups() && reg.isGroupStruct(paramType):
+			// Named groups fill the fields of a struct rather than
+			// being passed positionally.
+			arg, err := reg.bindGroups(r, paramType)
+			if err != nil {
+				return nil, &stepFailure{err}
+			}
+			args = append(args, arg)
+			regexGroupIdx = len(r.Names)
+			continue
+		case rt.IsVariadic() && stepArgIdx == numStepArgs-1:
+			// The final parameter collects the remaining groups, or
+			// the list captured by a single group.
+			arg, err := reg.variadicArg(r, regexGroupIdx, paramType)
+			if err != nil {
+				return nil, &stepFailure{err}
+			}
+			args = append(args, arg)
+			regexGroupIdx = len(r.Names)
+			continue
+		case r.DocString != nil && regexGroupIdx == len(r.Names) && isDocStringTarget(paramType):
+			arg, err := r.DocString.decode(paramType)
+			if err != nil {
+				return nil, &stepFailure{err}
 			}
+			args = append(args, arg)
+			regexGroupIdx++
+			continue
+		case regexGroupIdx >= len(r.Args):
+			if paramType.Kind() != reflect.String {
+				return nil, &stepFailure{fmt.Errorf(
+					"%s: %s: the step's argument %d (%v) has no group to be matched by",
+					r.Location,
+					r.Line,
+					stepArgIdx+1,
+					paramType,
+				)}
+			}
+			Warning.Println("Assuming a doc string will be passed in.")
+			args = append(args, reflect.Zero(paramType))
+			regexGroupIdx++
+			continue
 		}
 
-		reflect.ValueOf(r.Runner).Call(args)
+		arg, err := reg.convertGroup(r, regexGroupIdx, paramType)
+		if err != nil {
+			return nil, &stepFailure{r.argError(regexGroupIdx, err)}
+		}
+		args = append(args, arg)
+		regexGroupIdx++
 	}
-	return stepVal, nil
+	return args, nil
 }
 
 func readFeatureFile(f os.FileInfo) (string, error) {
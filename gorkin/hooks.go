@@ -0,0 +1,336 @@
+package gorkin
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"time"
+)
+
+// Status is the outcome of a step or scenario.
+type Status int
+
+const (
+	// StatusPassed means every step ran without failing.
+	StatusPassed Status = iota
+	// StatusFailed means a step or hook failed.
+	StatusFailed
+	// StatusSkipped means the step didn't run because an earlier
+	// step or hook failed or was pending.
+	StatusSkipped
+	// StatusPending means the step hasn't been implemented yet: it
+	// returned or panicked with ErrPending. The rest of its scenario
+	// is skipped, but the scenario doesn't fail.
+	StatusPending
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPassed:
+		return "passed"
+	case StatusFailed:
+		return "failed"
+	case StatusSkipped:
+		return "skipped"
+	case StatusPending:
+		return "pending"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// FeatureInfo describes the feature being run to hooks.
+type FeatureInfo struct {
+	// Name is the feature's description.
+	Name string
+	// Path is the feature's file.
+	Path string
+	// Tags are the tags declared above the feature.
+	Tags []string
+}
+
+// ScenarioInfo describes the scenario being run to hooks.
+type ScenarioInfo struct {
+	// Feature is the feature the scenario belongs to.
+	Feature *FeatureInfo
+	// Name is the scenario's description.
+	Name string
+	// Location is the feature file and line of the scenario.
+	Location string
+	// Tags are the tags declared above the scenario and its feature.
+	Tags []string
+	// Status is the outcome of the scenario so far.
+	Status Status
+}
+
+// StepInfo describes the step being run to hooks.
+type StepInfo struct {
+	// Scenario is the scenario the step belongs to.
+	Scenario *ScenarioInfo
+	// Parent is the step which ran this one with RunStep, or nil if
+	// the step is from the feature.
+	Parent *StepInfo
+	// Text is the step's line from the feature, including its
+	// keyword.
+	Text string
+	// Location is the feature file and line of the step.
+	Location string
+	// Pattern is the pattern of the step definition which matched.
+	Pattern string
+	// Definition is where the function of the step definition which
+	// matched is defined.
+	Definition string
+	// Args are the text captured by each group of Pattern which
+	// took part in the match.
+	Args []*StepArg
+	// Status is the outcome of the step. It is only meaningful to
+	// AfterStep hooks.
+	Status Status
+	// Err describes why the step failed, if it failed with an error
+	// rather than by failing its *testing.T.
+	Err error
+	// Duration is how long the step took to run. It is only
+	// meaningful to AfterStep hooks.
+	Duration time.Duration
+	// Attachments are the data attached to the step with Attach.
+	Attachments []*Attachment
+}
+
+// StepArg is the text a group of a step's pattern captured.
+type StepArg struct {
+	// Value is the captured text.
+	Value string
+	// Offset is the byte offset of Value within the step's Text.
+	Offset int
+}
+
+// hookKind identifies when a hook is run.
+type hookKind int
+
+const (
+	beforeSuite hookKind = iota
+	afterSuite
+	beforeFeature
+	afterFeature
+	beforeScenario
+	afterScenario
+	beforeStep
+	afterStep
+)
+
+// isAfter reports whether hooks of this kind run after what they
+// surround. These are run in the reverse of the order they were
+// registered in.
+func (k hookKind) isAfter() bool {
+	switch k {
+	case afterSuite, afterFeature, afterScenario, afterStep:
+		return true
+	}
+	return false
+}
+
+// hook is a function registered to run around suites, features,
+// scenarios or steps.
+type hook struct {
+	// Fn is the function to run.
+	Fn reflect.Value
+	// Tags restricts the hook to scenarios whose tags match it. A
+	// nil Tags matches every scenario.
+	Tags tagExpression
+}
+
+// newHook validates f, which may accept any of the values a scope can
+// inject and may return an error.
+func newHook(f interface{}) (*hook, error) {
+	fn := reflect.ValueOf(f)
+	if fn.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Hooks must be functions, not %T", f)
+	}
+	switch ft := fn.Type(); {
+	case ft.NumOut() == 0:
+	case ft.NumOut() == 1 && ft.Out(0) == errorType:
+	default:
+		return nil, fmt.Errorf("Hooks may only return an error, not %v", ft)
+	}
+	return &hook{Fn: fn}, nil
+}
+
+// call runs the hook with arguments injected from sc. Panics and
+// returned errors are both reported as errors.
+func (h *hook) call(sc *scope) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("hook %s panicked: %v\n%s", funcLocation(h.Fn.Interface()), p, debug.Stack())
+		}
+	}()
+
+	ft := h.Fn.Type()
+	args := make([]reflect.Value, ft.NumIn())
+	for i := range args {
+		arg, ok, err := sc.inject(ft.In(i))
+		if err != nil {
+			return fmt.Errorf("hook %s: %v", funcLocation(h.Fn.Interface()), err)
+		} else if !ok {
+			return fmt.Errorf("hook %s accepts a %v, which gorkin cannot provide",
+				funcLocation(h.Fn.Interface()),
+				ft.In(i),
+			)
+		}
+		args[i] = arg
+	}
+
+	if out := h.Fn.Call(args); len(out) == 1 && !out[0].IsNil() {
+		return fmt.Errorf("hook %s: %v", funcLocation(h.Fn.Interface()), out[0].Interface())
+	}
+	return nil
+}
+
+// addHook registers f to be run at the time kind identifies.
+func (r *Registry) addHook(kind hookKind, f interface{}) {
+	h, err := newHook(f)
+	must(err)
+	r.appendHook(kind, h)
+}
+
+// addTaggedHook registers f to be run at the time kind identifies,
+// but only for scenarios matching the tag expression expr.
+func (r *Registry) addTaggedHook(kind hookKind, expr string, f interface{}) {
+	h, err := newHook(f)
+	must(err)
+	h.Tags, err = parseTagExpression(expr)
+	must(err)
+	r.appendHook(kind, h)
+}
+
+// appendHook adds h to the hooks of the given kind. As with Step, a
+// hook whose function is already registered for the same kind and tag
+// expression, as happens with -count=2, replaces the older one rather
+// than being run twice.
+func (r *Registry) appendHook(kind hookKind, h *hook) {
+	if r.hooks == nil {
+		r.hooks = make(map[hookKind][]*hook)
+	}
+	for i, existing := range r.hooks[kind] {
+		if sameFunc(existing.Fn.Interface(), h.Fn.Interface()) && sameTags(existing.Tags, h.Tags) {
+			r.hooks[kind][i] = h
+			return
+		}
+	}
+	r.hooks[kind] = append(r.hooks[kind], h)
+}
+
+// sameTags reports whether a and b are the same tag expression. Either
+// may be nil.
+func sameTags(a, b tagExpression) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.String() == b.String()
+}
+
+// runHooks runs every hook of the given kind, in registration order
+// for Before hooks and in reverse for After hooks. Every hook is run
+// even if an earlier one fails; the errors are returned.
+func (r *Registry) runHooks(kind hookKind, sc *scope) []error {
+	hooks := r.hooks[kind]
+	var errs []error
+	for i := range hooks {
+		h := hooks[i]
+		if kind.isAfter() {
+			h = hooks[len(hooks)-1-i]
+		}
+		if h.Tags != nil && (sc.Scenario == nil || !h.Tags.matches(sc.Scenario.Tags)) {
+			continue
+		}
+		if err := h.call(sc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// BeforeSuite registers f to be run before any feature is run by
+// RunFeatureTests.
+//
+// Hooks may accept any of *testing.T, the isolation type, *FeatureInfo,
+// *ScenarioInfo and *StepInfo, and may return an error to fail
+// whatever they surround. Values which don't exist yet, such as the
+// isolation value before a scenario, are passed as nil. Before hooks
+// are run in the order they were registered and After hooks in the
+// reverse order. After hooks are always run, even if a step or hook
+// failed or panicked.
+func (r *Registry) BeforeSuite(f interface{}) { r.addHook(beforeSuite, f) }
+
+// AfterSuite registers f to be run after every feature has been run
+// by RunFeatureTests. See BeforeSuite.
+func (r *Registry) AfterSuite(f interface{}) { r.addHook(afterSuite, f) }
+
+// BeforeFeature registers f to be run before each feature. See
+// BeforeSuite.
+func (r *Registry) BeforeFeature(f interface{}) { r.addHook(beforeFeature, f) }
+
+// AfterFeature registers f to be run after each feature. See
+// BeforeSuite.
+func (r *Registry) AfterFeature(f interface{}) { r.addHook(afterFeature, f) }
+
+// BeforeScenario registers f to be run before each scenario, after
+// its isolation value has been created. See BeforeSuite.
+func (r *Registry) BeforeScenario(f interface{}) { r.addHook(beforeScenario, f) }
+
+// AfterScenario registers f to be run after each scenario. The
+// *ScenarioInfo passed to it holds the scenario's result. See
+// BeforeSuite.
+func (r *Registry) AfterScenario(f interface{}) { r.addHook(afterScenario, f) }
+
+// Before registers f to be run before each scenario whose tags match
+// the tag expression expr, e.g. "@db and not @readonly". Expressions
+// combine tags with "and", "or", "not" and parentheses. A scenario has
+// its own tags and those of its feature. It panics if expr isn't a
+// valid tag expression. See BeforeSuite.
+func (r *Registry) Before(expr string, f interface{}) { r.addTaggedHook(beforeScenario, expr, f) }
+
+// After registers f to be run after each scenario whose tags match
+// the tag expression expr. See Before.
+func (r *Registry) After(expr string, f interface{}) { r.addTaggedHook(afterScenario, expr, f) }
+
+// BeforeStep registers f to be run before each step. See BeforeSuite.
+func (r *Registry) BeforeStep(f interface{}) { r.addHook(beforeStep, f) }
+
+// AfterStep registers f to be run after each step which was run. The
+// *StepInfo passed to it holds the step's result. See BeforeSuite.
+func (r *Registry) AfterStep(f interface{}) { r.addHook(afterStep, f) }
+
+// BeforeSuite registers f with DefaultRegistry. See
+// Registry.BeforeSuite.
+func BeforeSuite(f interface{}) { DefaultRegistry.BeforeSuite(f) }
+
+// AfterSuite registers f with DefaultRegistry. See
+// Registry.AfterSuite.
+func AfterSuite(f interface{}) { DefaultRegistry.AfterSuite(f) }
+
+// BeforeFeature registers f with DefaultRegistry. See
+// Registry.BeforeFeature.
+func BeforeFeature(f interface{}) { DefaultRegistry.BeforeFeature(f) }
+
+// AfterFeature registers f with DefaultRegistry. See
+// Registry.AfterFeature.
+func AfterFeature(f interface{}) { DefaultRegistry.AfterFeature(f) }
+
+// BeforeScenario registers f with DefaultRegistry. See
+// Registry.BeforeScenario.
+func BeforeScenario(f interface{}) { DefaultRegistry.BeforeScenario(f) }
+
+// AfterScenario registers f with DefaultRegistry. See
+// Registry.AfterScenario.
+func AfterScenario(f interface{}) { DefaultRegistry.AfterScenario(f) }
+
+// Before registers f with DefaultRegistry. See Registry.Before.
+func Before(expr string, f interface{}) { DefaultRegistry.Before(expr, f) }
+
+// After registers f with DefaultRegistry. See Registry.After.
+func After(expr string, f interface{}) { DefaultRegistry.After(expr, f) }
+
+// BeforeStep registers f with DefaultRegistry. See Registry.BeforeStep.
+func BeforeStep(f interface{}) { DefaultRegistry.BeforeStep(f) }
+
+// AfterStep registers f with DefaultRegistry. See Registry.AfterStep.
+func AfterStep(f interface{}) { DefaultRegistry.AfterStep(f) }
@@ -0,0 +1,207 @@
+package gorkin
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterFormatter("junit", func(w io.Writer) Formatter {
+		return &junitFormatter{w: w}
+	})
+}
+
+// The elements of a JUnit XML report.
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name          `xml:"testsuites"`
+		Name     string            `xml:"name,attr"`
+		Tests    int               `xml:"tests,attr"`
+		Failures int               `xml:"failures,attr"`
+		Errors   int               `xml:"errors,attr"`
+		Skipped  int               `xml:"skipped,attr"`
+		Time     string            `xml:"time,attr"`
+		Suites   []*junitTestSuite `xml:"testsuite"`
+	}
+
+	junitTestSuite struct {
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Errors   int              `xml:"errors,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Time     string           `xml:"time,attr"`
+		Cases    []*junitTestCase `xml:"testcase"`
+
+		start time.Time
+	}
+
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitProblem `xml:"failure,omitempty"`
+		Error     *junitProblem `xml:"error,omitempty"`
+		Skipped   *junitProblem `xml:"skipped,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+
+		start time.Time
+		// steps are kept in the order they started, so that nested
+		// steps follow the step which ran them.
+		steps []*StepInfo
+	}
+
+	junitProblem struct {
+		Message string `xml:"message,attr,omitempty"`
+		Type    string `xml:"type,attr,omitempty"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// junitFormatter writes a JUnit XML report once the run has finished.
+// Each feature is a testsuite and each scenario a testcase, whose
+// system-out lists its steps and their results. Features which can't
+// be parsed are reported as a testcase with an error.
+type junitFormatter struct {
+	BaseFormatter
+	w io.Writer
+
+	report junitTestSuites
+	start  time.Time
+	suite  *junitTestSuite
+	tc     *junitTestCase
+	// failedLines describes the lines of the current feature which
+	// couldn't be parsed or matched.
+	failedLines []string
+}
+
+func (f *junitFormatter) SuiteStarted() {
+	f.report = junitTestSuites{Name: "gorkin"}
+	f.start = time.Now()
+}
+
+func (f *junitFormatter) FeatureStarted(info *FeatureInfo) {
+	name := info.Name
+	if name == "" {
+		name = info.Path
+	}
+	f.suite = &junitTestSuite{Name: name, start: time.Now()}
+	f.failedLines = nil
+}
+
+func (f *junitFormatter) LineParsed(l *LineInfo) {
+	if l.Err != nil {
+		f.failedLines = append(f.failedLines, fmt.Sprintf("%s: %s: %v", l.Location, l.Text, l.Err))
+	}
+}
+
+func (f *junitFormatter) ScenarioStarted(s *ScenarioInfo) {
+	f.tc = &junitTestCase{
+		Name:      s.Name,
+		ClassName: f.suite.Name,
+		start:     time.Now(),
+	}
+}
+
+func (f *junitFormatter) StepStarted(s *StepInfo) {
+	f.tc.steps = append(f.tc.steps, s)
+}
+
+func (f *junitFormatter) StepFinished(s *StepInfo) {
+	if s.Status == StatusFailed && f.tc.Failure == nil {
+		message := fmt.Sprintf("%s: %s failed", s.Location, s.Text)
+		text := message
+		if s.Err != nil {
+			// The first line of the error is enough for a summary;
+			// stack traces follow it.
+			text = s.Err.Error()
+			message = strings.SplitN(text, "\n", 2)[0]
+		}
+		f.tc.Failure = &junitProblem{Message: message, Type: "failed", Text: text}
+	}
+}
+
+func (f *junitFormatter) ScenarioFinished(s *ScenarioInfo) {
+	tc := f.tc
+	tc.Time = seconds(time.Since(tc.start))
+
+	var out strings.Builder
+	for _, step := range tc.steps {
+		// Nested steps are indented beneath the step which ran them.
+		for parent := step.Parent; parent != nil; parent = parent.Parent {
+			out.WriteString("  ")
+		}
+		fmt.Fprintf(&out, "%s ... %s\n", step.Text, step.Status)
+	}
+	tc.SystemOut = out.String()
+
+	switch {
+	case s.Status == StatusFailed && tc.Failure == nil:
+		// A hook or the isolation value failed the scenario.
+		tc.Failure = &junitProblem{Message: "scenario failed outside of its steps", Type: "failed"}
+	case s.Status == StatusSkipped:
+		tc.Skipped = &junitProblem{}
+	case s.Status == StatusPending:
+		tc.Skipped = &junitProblem{Message: "pending"}
+	}
+
+	f.suite.Cases = append(f.suite.Cases, tc)
+	f.tc = nil
+}
+
+func (f *junitFormatter) FeatureFinished(info *FeatureInfo) {
+	suite := f.suite
+	if len(f.failedLines) > 0 {
+		suite.Cases = append(suite.Cases, &junitTestCase{
+			Name:      suite.Name,
+			ClassName: suite.Name,
+			Time:      seconds(0),
+			Error: &junitProblem{
+				Message: "the feature could not be parsed",
+				Type:    "parse",
+				Text:    strings.Join(f.failedLines, "\n"),
+			},
+		})
+	}
+
+	suite.Time = seconds(time.Since(suite.start))
+	for _, tc := range suite.Cases {
+		suite.Tests++
+		switch {
+		case tc.Failure != nil:
+			suite.Failures++
+		case tc.Error != nil:
+			suite.Errors++
+		case tc.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+
+	f.report.Suites = append(f.report.Suites, suite)
+	f.report.Tests += suite.Tests
+	f.report.Failures += suite.Failures
+	f.report.Errors += suite.Errors
+	f.report.Skipped += suite.Skipped
+	f.suite = nil
+}
+
+func (f *junitFormatter) SuiteFinished() {
+	f.report.Time = seconds(time.Since(f.start))
+
+	io.WriteString(f.w, xml.Header)
+	enc := xml.NewEncoder(f.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(f.report); err != nil {
+		log.Printf("could not write JUnit report: %v", err)
+	}
+	io.WriteString(f.w, "\n")
+}
+
+// seconds formats d as JUnit reports expect times.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
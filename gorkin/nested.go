@@ -0,0 +1,68 @@
+package gorkin
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// scopeKey is the context key of the scope a step is running in.
+type scopeKey struct{}
+
+// RunStep runs text, a step such as `Given the user "bob" exists`,
+// from within another step. ctx must be the context.Context gorkin
+// passed to the calling step. The step is matched against the same
+// registry and run with the same isolation value and provided values
+// as the calling step, and is reported to hooks as a step whose
+// Parent is the calling step.
+//
+// If the step can't be matched or fails, the calling step fails too
+// and the error returned describes the nested step. The failure has
+// already been reported, so the calling step need only return, though
+// it may return the error without it being reported again. A nested
+// step which calls t.FailNow stops the calling step at once.
+func RunStep(ctx context.Context, text string) error {
+	sc, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok || sc.Step == nil {
+		return fmt.Errorf("RunStep must be given the context passed to a running step")
+	}
+	parent := sc.Step
+
+	fail := func(err error) error {
+		err = fmt.Errorf("%s: %s: nested step %q: %v", parent.Location, parent.Text, text, err)
+		sc.T.Error(err)
+		parent.Err = err
+		return &reportedError{err}
+	}
+
+	r, err := sc.Registry.parseStep(text, nil)
+	if err != nil {
+		return fail(err)
+	}
+	r.Line = text
+	r.Location = parent.Location
+	r.Language = sc.Language
+
+	stepInfo, err := runStepWithHooks(sc.Registry, r, sc)
+	if err != nil {
+		return fail(err)
+	} else if stepInfo.Err != nil {
+		// The nested step's error already says where it failed.
+		parent.Err = stepInfo.Err
+		return &reportedError{stepInfo.Err}
+	} else if stepInfo.Status != StatusPassed {
+		return fail(fmt.Errorf("the step defined at %s %s", r.StepInfo(), stepInfo.Status))
+	}
+	return nil
+}
+
+// reportedError is an error from a nested step which has already been
+// reported, so that the calling step returning it doesn't report it
+// again.
+type reportedError struct {
+	error
+}
+
+func (e *reportedError) Unwrap() error { return e.error }
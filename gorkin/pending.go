@@ -0,0 +1,24 @@
+package gorkin
+
+import (
+	"errors"
+)
+
+// ErrPending is returned, or panicked with, by steps which haven't
+// been implemented yet. The step is reported as pending and the rest
+// of its scenario is skipped without failing the test.
+var ErrPending = errors.New("pending")
+
+// Pending is a step function for steps which are yet to be written,
+// e.g.
+//
+//	Step(`the user logs in`, Pending)
+//
+// It panics with ErrPending, and dry runs report the steps it's
+// registered for as pending.
+func Pending() { panic(ErrPending) }
+
+// isPending reports whether r's step function is Pending.
+func (r *runnerAndArgs) isPending() bool {
+	return sameFunc(r.Runner, Pending)
+}
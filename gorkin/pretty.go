@@ -0,0 +1,238 @@
+package gorkin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterFormatter("pretty", newPrettyFormatter)
+}
+
+// ANSI escape codes used by the pretty formatter.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiNormal = "\x1b[22m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+	ansiGrey   = "\x1b[90m"
+)
+
+// statusColors holds the colour each status is printed in.
+var statusColors = map[Status]string{
+	StatusPassed:  ansiGreen,
+	StatusFailed:  ansiRed,
+	StatusSkipped: ansiCyan,
+	StatusPending: ansiYellow,
+}
+
+// prettyFormatter prints features as they're written, with each step
+// coloured by its status and followed by where its definition is.
+// Runs end with a summary of how many scenarios and steps passed.
+type prettyFormatter struct {
+	w     io.Writer
+	color bool
+
+	start time.Time
+	// width is the column at which comments begin for the current
+	// feature.
+	width int
+	// failedLines holds the lines of the current feature which
+	// couldn't be parsed or matched.
+	failedLines []*LineInfo
+
+	scenarios map[Status]int
+	steps     map[Status]int
+}
+
+func newPrettyFormatter(w io.Writer) Formatter {
+	return &prettyFormatter{
+		w:         w,
+		color:     useColor(w),
+		scenarios: make(map[Status]int),
+		steps:     make(map[Status]int),
+	}
+}
+
+// useColor reports whether output to w should be coloured: only when
+// w is a terminal and colour hasn't been turned off with NO_COLOR or
+// -gorkin.no-color, unless -gorkin.color forces it on. go test given a
+// list of packages captures their output, so it is never a terminal.
+func useColor(w io.Writer) bool {
+	if *noColor {
+		return false
+	} else if *color {
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in the given colour, if colour is on.
+func (f *prettyFormatter) paint(color, s string) string {
+	if !f.color || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func (f *prettyFormatter) SuiteStarted() {
+	f.start = time.Now()
+}
+
+func (f *prettyFormatter) FeatureStarted(info *FeatureInfo) {
+	f.width = 0
+	f.failedLines = nil
+	if len(info.Tags) > 0 {
+		fmt.Fprintln(f.w, f.paint(ansiCyan, strings.Join(info.Tags, " ")))
+	}
+	if info.Name != "" {
+		fmt.Fprintf(f.w, "Feature: %s\n", info.Name)
+	} else {
+		fmt.Fprintf(f.w, "Feature: %s\n", info.Path)
+	}
+}
+
+func (f *prettyFormatter) LineParsed(l *LineInfo) {
+	// Scenarios are indented by two spaces and steps by four.
+	indent := 4
+	if strings.HasPrefix(l.Text, "Scenario") || strings.HasPrefix(l.Text, "Background") {
+		indent = 2
+	}
+	if width := indent + utf8.RuneCountInString(l.Text); l.Definition != "" || indent == 2 {
+		if width > f.width {
+			f.width = width
+		}
+	}
+	if l.Err != nil {
+		f.failedLines = append(f.failedLines, l)
+	}
+}
+
+func (f *prettyFormatter) ScenarioStarted(s *ScenarioInfo) {
+	fmt.Fprintln(f.w)
+	if len(s.Tags) > len(s.Feature.Tags) {
+		tags := s.Tags[len(s.Feature.Tags):]
+		fmt.Fprintln(f.w, "  "+f.paint(ansiCyan, strings.Join(tags, " ")))
+	}
+	if s.Location != "" {
+		f.printWithComment("  Scenario: "+s.Name, s.Location)
+	}
+}
+
+func (f *prettyFormatter) StepStarted(s *StepInfo) {}
+
+func (f *prettyFormatter) StepFinished(s *StepInfo) {
+	// Nested steps fail the step which ran them, which is reported
+	// instead.
+	if s.Parent != nil {
+		return
+	}
+	f.steps[s.Status]++
+
+	color := statusColors[s.Status]
+	text := f.paint(color, f.highlightArgs(s, color))
+	width := 4 + utf8.RuneCountInString(s.Text)
+	fmt.Fprintf(f.w, "    %s%s\n", text, f.comment(width, s.Definition))
+
+	if s.Err != nil {
+		for _, line := range strings.Split(s.Err.Error(), "\n") {
+			fmt.Fprintln(f.w, "      "+f.paint(ansiRed, line))
+		}
+	}
+}
+
+func (f *prettyFormatter) ScenarioFinished(s *ScenarioInfo) {
+	f.scenarios[s.Status]++
+}
+
+func (f *prettyFormatter) FeatureFinished(info *FeatureInfo) {
+	for _, l := range f.failedLines {
+		fmt.Fprintf(f.w, "\n  %s\n", f.paint(ansiYellow, l.Text))
+		fmt.Fprintf(f.w, "    %s\n", f.paint(ansiRed, fmt.Sprintf("%s: %v", l.Location, l.Err)))
+	}
+	fmt.Fprintln(f.w)
+}
+
+func (f *prettyFormatter) SuiteFinished() {
+	fmt.Fprintf(f.w, "%s, %s\n%v\n",
+		f.summary(f.scenarios, "scenario"),
+		f.summary(f.steps, "step"),
+		time.Since(f.start).Round(time.Millisecond),
+	)
+}
+
+// printWithComment prints line followed by comment, aligned with the
+// other comments of the feature.
+func (f *prettyFormatter) printWithComment(line, comment string) {
+	fmt.Fprintf(f.w, "%s%s\n", line, f.comment(utf8.RuneCountInString(line), comment))
+}
+
+// comment returns the text which follows a line width runes long to
+// show comment in the feature's comment column.
+func (f *prettyFormatter) comment(width int, comment string) string {
+	if comment == "" {
+		return ""
+	}
+	padding := 1
+	if f.width >= width {
+		padding += f.width - width
+	}
+	return strings.Repeat(" ", padding) + f.paint(ansiGrey, "# "+comment)
+}
+
+// highlightArgs returns the step's text with the text its groups
+// captured in bold. color is the colour of the rest of the text.
+func (f *prettyFormatter) highlightArgs(s *StepInfo, color string) string {
+	if !f.color || len(s.Args) == 0 {
+		return s.Text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, arg := range s.Args {
+		end := arg.Offset + len(arg.Value)
+		if arg.Offset < last || end > len(s.Text) {
+			continue
+		}
+		b.WriteString(s.Text[last:arg.Offset])
+		b.WriteString(ansiBold + arg.Value + ansiNormal + color)
+		last = end
+	}
+	b.WriteString(s.Text[last:])
+	return b.String()
+}
+
+// summary describes counts, e.g. "3 scenarios (2 passed, 1 failed)".
+func (f *prettyFormatter) summary(counts map[Status]int, noun string) string {
+	total := 0
+	var parts []string
+	for _, status := range []Status{StatusPassed, StatusFailed, StatusPending, StatusSkipped} {
+		if n := counts[status]; n > 0 {
+			total += n
+			parts = append(parts, f.paint(statusColors[status], fmt.Sprintf("%d %s", n, status)))
+		}
+	}
+
+	if total != 1 {
+		noun += "s"
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d %s", total, noun)
+	}
+	return fmt.Sprintf("%d %s (%s)", total, noun, strings.Join(parts, ", "))
+}
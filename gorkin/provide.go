@@ -0,0 +1,96 @@
+package gorkin
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// provider constructs values of one type for steps and hooks which
+// ask for them.
+type provider struct {
+	// Fn is the constructor.
+	Fn reflect.Value
+	// Type is the type of value Fn returns.
+	Type reflect.Type
+}
+
+// newProvider validates constructor, which must return a single value,
+// optionally followed by an error.
+func newProvider(constructor interface{}) (*provider, error) {
+	fn := reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func {
+		return nil, fmt.Errorf("Constructors must be functions, not %T", constructor)
+	}
+	switch ft := fn.Type(); {
+	case ft.NumOut() == 1:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+	default:
+		return nil, fmt.Errorf("Constructors must return a value and optionally an error, not %v", ft)
+	}
+
+	p := &provider{Fn: fn, Type: fn.Type().Out(0)}
+	// Other types could be mistaken for arguments captured from steps.
+	if k := p.Type.Kind(); k != reflect.Ptr && k != reflect.Interface {
+		return nil, fmt.Errorf("Constructors must return a pointer or an interface, not %v", p.Type)
+	}
+	switch p.Type {
+	case testingTType, featureInfoType, scenarioInfoType, stepInfoType, contextType, errorType:
+		return nil, fmt.Errorf("gorkin already provides values of type %v", p.Type)
+	}
+	return p, nil
+}
+
+// construct calls the constructor with arguments injected from sc.
+func (p *provider) construct(sc *scope) (reflect.Value, error) {
+	ft := p.Fn.Type()
+	args := make([]reflect.Value, ft.NumIn())
+	for i := range args {
+		arg, ok, err := sc.inject(ft.In(i))
+		if err != nil {
+			return reflect.Value{}, err
+		} else if !ok {
+			return reflect.Value{}, fmt.Errorf("constructor %s accepts a %v, which gorkin cannot provide",
+				funcLocation(p.Fn.Interface()),
+				ft.In(i),
+			)
+		}
+		args[i] = arg
+	}
+
+	out := p.Fn.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("constructor %s: %v", funcLocation(p.Fn.Interface()), out[1].Interface())
+	}
+	return out[0], nil
+}
+
+// Provide registers constructor to create values of the type it
+// returns, which must be a pointer or an interface. Steps and hooks
+// which accept that type are given a value shared by the rest of the
+// scenario; it is only constructed the first time the scenario asks
+// for it.
+//
+// The constructor may accept anything a step can be injected with,
+// including the values of other constructors, and may return an
+// error as its second result to fail the step which asked for the
+// value. If the value has a Teardown(*testing.T) error or Close()
+// error method, it is called when the scenario ends, in the reverse
+// order the values were constructed in. Hooks run outside a scenario
+// are given the zero value. It panics if the type is already
+// provided by a different constructor; registering the same one again,
+// as happens with -count=2, replaces it.
+func (r *Registry) Provide(constructor interface{}) {
+	p, err := newProvider(constructor)
+	must(err)
+	if existing, ok := r.providers[p.Type]; ok && !sameFunc(existing.Fn.Interface(), constructor) {
+		panic(fmt.Errorf("a constructor for %v is already registered", p.Type))
+	}
+	if r.providers == nil {
+		r.providers = make(map[reflect.Type]*provider)
+	}
+	r.providers[p.Type] = p
+}
+
+// Provide registers constructor with DefaultRegistry. See
+// Registry.Provide.
+func Provide(constructor interface{}) { DefaultRegistry.Provide(constructor) }
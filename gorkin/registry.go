@@ -0,0 +1,261 @@
+package gorkin
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// DefaultRegistry is the registry used by the package-level
+// functions such as Step and RunFeatureTests. Steps registered
+// against it are shared by every test in the package for maximum
+// reusability.
+var DefaultRegistry = NewRegistry()
+
+// Registry holds a set of step definitions and the options used when
+// running features against them. Tests which would like their steps
+// kept apart from other tests in the same package, or step libraries
+// which define the same phrase differently, can each use their own
+// registry.
+type Registry struct {
+	// Options control how features are found and run.
+	Options Options
+
+	// steps are kept in the order they were registered.
+	steps []*stepDefinition
+
+	// paramTypes are the custom parameter types, kept in the order
+	// they were registered.
+	paramTypes []*parameterType
+
+	// hooks are kept in the order they were registered.
+	hooks map[hookKind][]*hook
+
+	// providers construct the values steps ask for by type.
+	providers map[reflect.Type]*provider
+
+	// used holds the patterns of the steps which have matched a line
+	// since RunFeatureTests was last called.
+	used map[string]bool
+
+	// format receives the events of the current run.
+	format Formatter
+}
+
+// Options control how a Registry finds and runs features.
+type Options struct {
+	// FeaturesDir is the directory which is searched for feature
+	// files. When empty, the parent directory of the steps package
+	// is used.
+	FeaturesDir string
+
+	// MatchPolicy decides which step is run when a line in a
+	// feature matches more than one step.
+	MatchPolicy MatchPolicy
+
+	// Unanchored allows step patterns to match anywhere within the
+	// text of a step rather than having to match all of it.
+	Unanchored bool
+}
+
+// MatchPolicy decides which step is run when a line in a feature
+// matches more than one step.
+type MatchPolicy int
+
+const (
+	// MatchStrict fails any line which matches more than one step,
+	// listing every step it matched. This is the default.
+	MatchStrict MatchPolicy = iota
+
+	// MatchFirstRegistered runs whichever matching step was
+	// registered first.
+	MatchFirstRegistered
+
+	// MatchMostSpecific runs the matching step whose pattern
+	// requires the most literal text. Ties go to whichever was
+	// registered first.
+	MatchMostSpecific
+)
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Step registers f to be run for any step which matches regex.
+//
+// A step's text is the line from the feature without its leading
+// keyword (Given, When, Then, And, But or *) and surrounding space.
+// regex must match all of that text, as if it were written
+// ^(?:regex)$, unless Options.Unanchored is set. Each group in regex
+// becomes an argument to f. f may return an error as its last result
+// to fail the step, or ErrPending to mark it pending.
+//
+// Groups which are optional and take no part in a match are passed
+// as the zero value of their parameter, e.g. nil for pointers. If f is
+// variadic, its final parameter collects the remaining groups, or, if
+// only one group remains, the items of the list it captured, e.g.
+// `"a", "b" and "c"`.
+//
+// When regex has named groups and f accepts a struct, the groups fill
+// the struct's fields instead. A field is filled by the group named in
+// its tag, e.g. `gorkin:"count"`, or else by the group whose name
+// matches the field's name ignoring case.
+//
+// regex may instead be a Cucumber Expression such as
+// `they should see {int} example(s)`. Any pattern which refers to a
+// parameter type ({int}, {float}, {word}, {string}, {bigint} or {})
+// and neither begins with ^ nor ends with $ is treated as one; use
+// StepExpression for an expression without parameters. Each
+// parameter becomes an argument to f, converted to the parameter
+// type's Go type; text in parentheses is optional, and words
+// separated by / are alternatives. Expressions always match the whole
+// text of a step.
+//
+// Registering the same regex twice is an error unless both
+// registrations come from the same function literal, which happens
+// when the steps are registered within a test run more than once
+// (e.g. with -count=2). In that case the newer registration replaces
+// the older one.
+func (r *Registry) Step(regex string, f runner) {
+	r.addStep(regex, r.isExpression(regex), f)
+}
+
+// StepExpression registers f to be run for any step which matches the
+// Cucumber Expression expr, e.g. `I have a/an apple(s)`, whether or
+// not it refers to a parameter type. Otherwise it behaves as Step.
+func (r *Registry) StepExpression(expr string, f runner) {
+	r.addStep(expr, true, f)
+}
+
+// addStep compiles pattern and registers it, replacing an earlier
+// registration of the same pattern and function.
+func (r *Registry) addStep(pattern string, expression bool, f runner) {
+	def, err := r.compileStep(pattern, expression, f)
+	must(err)
+	for i, existing := range r.steps {
+		if existing.Pattern == pattern && sameFunc(existing.Runner, f) {
+			r.steps[i] = def
+			return
+		}
+	}
+	must(runnerExists(pattern, r.steps))
+	r.steps = append(r.steps, def)
+}
+
+// StepPatterner is implemented by types whose methods are steps. It
+// maps the names of methods to the patterns they're registered with.
+type StepPatterner interface {
+	StepPatterns() map[string]string
+}
+
+// RegisterMethods registers each method of steps named by its
+// StepPatterns as a step with the corresponding pattern, in the order
+// of the methods' names. When a step runs, the method's receiver is
+// the scenario's value of steps' type, so steps should be the
+// isolation value passed to RunFeatureTests or a type registered with
+// Provide. It panics if a name doesn't belong to an exported method.
+func (r *Registry) RegisterMethods(steps StepPatterner) {
+	patterns := steps.StepPatterns()
+	t := reflect.TypeOf(steps)
+	registered := 0
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if pattern, ok := patterns[method.Name]; ok {
+			r.Step(pattern, method.Func.Interface())
+			registered++
+		}
+	}
+
+	if registered < len(patterns) {
+		for name := range patterns {
+			if _, ok := t.MethodByName(name); !ok {
+				panic(fmt.Errorf("%v has no exported method %s for the step %q", t, name, patterns[name]))
+			}
+		}
+	}
+}
+
+// RunFeatureTests runs all feature files found in the registry's
+// features directory against the registry's steps. stepIsolater is
+// the type which will be instantiated for each scenario and passed to
+// any step which requests it.
+//
+// If the isolation type has a Setup(*testing.T) error method, it is
+// called before each scenario and its BeforeScenario hooks; a failure
+// fails the scenario without running its steps. Teardown(*testing.T)
+// error and io.Closer's Close are registered with the scenario's
+// t.Cleanup, in that order, and so run after the AfterScenario hooks
+// whether or not the scenario passed.
+func (r *Registry) RunFeatureTests(t *testing.T, stepIsolater interface{}) {
+	runFeatureTests(r, t, stepIsolater)
+}
+
+func (r *Registry) featuresDir() string {
+	if r.Options.FeaturesDir != "" {
+		return r.Options.FeaturesDir
+	}
+	// Steps should be in the steps folder under features
+	return ".."
+}
+
+// sameFunc reports whether a and b are both functions which share the
+// same code.
+func sameFunc(a, b runner) bool {
+	aVal, bVal := reflect.ValueOf(a), reflect.ValueOf(b)
+	if aVal.Kind() != reflect.Func || bVal.Kind() != reflect.Func {
+		return false
+	}
+	return aVal.Pointer() == bVal.Pointer()
+}
+
+// ParameterType registers a parameter type which can be referred to
+// as {name} from Cucumber Expressions. Text matching regex is passed
+// to convert, which must be a function of the form:
+//
+//	func(string) (T, error)
+//
+// Steps which accept a T for a group of a regex are also converted
+// with convert. Parameter types must be registered before any step
+// which refers to them.
+//
+// As with Step, registering a name twice is an error unless both
+// registrations have the same regex and convert function, in which
+// case the newer one replaces the older.
+func (r *Registry) ParameterType(name, regex string, convert interface{}) {
+	p, err := newParameterType(name, regex, convert)
+	must(err)
+	for i, existing := range r.paramTypes {
+		if existing.Name == name && existing.Regex == regex && sameFunc(existing.Converter, convert) {
+			r.paramTypes[i] = p
+			return
+		}
+	}
+	if _, ok := r.parameterType(name); ok {
+		panic(fmt.Errorf(`The parameter type {%s} already exists.`, name))
+	}
+	r.paramTypes = append(r.paramTypes, p)
+}
+
+// compileStep compiles pattern, which is a Cucumber Expression if
+// expression is true and a regex otherwise, into a step definition.
+func (r *Registry) compileStep(pattern string, expression bool, f runner) (*stepDefinition, error) {
+	def := &stepDefinition{Pattern: pattern, Runner: f, Expression: expression}
+
+	regex := pattern
+	if expression {
+		var err error
+		if regex, def.Params, err = r.compileExpression(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if def.Regex, err = regexp.Compile(regex); err != nil {
+		return nil, err
+	} else if def.Anchored, err = regexp.Compile(`^(?:` + regex + `)$`); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
@@ -0,0 +1,153 @@
+package gorkin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var (
+	testingTType     = reflect.TypeOf((*testing.T)(nil))
+	featureInfoType  = reflect.TypeOf((*FeatureInfo)(nil))
+	scenarioInfoType = reflect.TypeOf((*ScenarioInfo)(nil))
+	stepInfoType     = reflect.TypeOf((*StepInfo)(nil))
+)
+
+// scope holds the values which gorkin can inject into the parameters
+// of steps and hooks. Values which don't exist at the time, such as
+// the isolation value before a scenario starts, are injected as nil.
+type scope struct {
+	// T is the test, or the scenario's subtest, being run.
+	T *testing.T
+	// StepType is the pointer type of the isolation value.
+	StepType reflect.Type
+	// StepVal is the scenario's isolation value.
+	StepVal reflect.Value
+
+	Feature  *FeatureInfo
+	Scenario *ScenarioInfo
+	Step     *StepInfo
+
+	// Registry is the registry whose steps are being run.
+	Registry *Registry
+	// Language is the language of the feature being run.
+	Language string
+
+	// Providers construct the values of other types.
+	Providers map[reflect.Type]*provider
+	// Provided holds the values constructed so far in the scenario.
+	// It is nil outside of a scenario. A type which is still being
+	// constructed maps to the zero Value.
+	Provided map[reflect.Type]reflect.Value
+}
+
+// canInject reports whether gorkin has a value of type t to give,
+// without constructing it.
+func (sc *scope) canInject(t reflect.Type) bool {
+	switch t {
+	case testingTType, sc.StepType, featureInfoType, scenarioInfoType, stepInfoType, contextType:
+		return true
+	}
+	_, ok := sc.Providers[t]
+	return ok
+}
+
+// inject returns the value for a parameter of type t, or false if
+// gorkin has no value of that type to give. Provided values are
+// constructed the first time a scenario asks for them.
+func (sc *scope) inject(t reflect.Type) (reflect.Value, bool, error) {
+	switch t {
+	case testingTType:
+		return reflect.ValueOf(sc.T), true, nil
+	case sc.StepType:
+		if !sc.StepVal.IsValid() {
+			return reflect.Zero(t), true, nil
+		}
+		return sc.StepVal, true, nil
+	case featureInfoType:
+		return reflect.ValueOf(sc.Feature), true, nil
+	case scenarioInfoType:
+		return reflect.ValueOf(sc.Scenario), true, nil
+	case stepInfoType:
+		return reflect.ValueOf(sc.Step), true, nil
+	case contextType:
+		return reflect.ValueOf(context.WithValue(context.Background(), scopeKey{}, sc)), true, nil
+	}
+
+	p, ok := sc.Providers[t]
+	if !ok {
+		return reflect.Value{}, false, nil
+	}
+	if sc.Provided == nil {
+		return reflect.Zero(t), true, nil
+	}
+	if v, ok := sc.Provided[t]; ok {
+		if !v.IsValid() {
+			return reflect.Value{}, true, fmt.Errorf("the constructor for %v depends on itself", t)
+		}
+		return v, true, nil
+	}
+
+	sc.Provided[t] = reflect.Value{}
+	v, err := p.construct(sc)
+	if err != nil {
+		delete(sc.Provided, t)
+		return reflect.Value{}, true, err
+	}
+	sc.Provided[t] = v
+	if v.CanInterface() {
+		sc.cleanUp(v.Interface(), fmt.Sprintf("%v", t))
+	}
+	return v, true, nil
+}
+
+// setupper is implemented by isolation types which prepare each
+// scenario's isolation value before the scenario runs.
+type setupper interface {
+	Setup(t *testing.T) error
+}
+
+// teardowner is implemented by isolation types which clean up after
+// each scenario.
+type teardowner interface {
+	Teardown(t *testing.T) error
+}
+
+// setUpIsolation calls the Setup method of the scenario's isolation
+// value, if it has one, and registers its Teardown and Close methods
+// with the scenario's t.Cleanup. Teardown and Close are called even if
+// Setup fails, so they must cope with a partial set up.
+func (sc *scope) setUpIsolation() error {
+	isolation := sc.StepVal.Interface()
+	sc.cleanUp(isolation, "isolation value")
+
+	if setupper, ok := isolation.(setupper); ok {
+		if err := setupper.Setup(sc.T); err != nil {
+			return fmt.Errorf("could not set up isolation value: %v", err)
+		}
+	}
+	return nil
+}
+
+// cleanUp registers v's Teardown and Close methods, if it has them,
+// with t.Cleanup so that Teardown is called before Close. what
+// describes v in errors.
+func (sc *scope) cleanUp(v interface{}, what string) {
+	t := sc.T
+	if closer, ok := v.(io.Closer); ok {
+		t.Cleanup(func() {
+			if err := closer.Close(); err != nil {
+				t.Errorf("could not close %s: %v", what, err)
+			}
+		})
+	}
+	if teardowner, ok := v.(teardowner); ok {
+		t.Cleanup(func() {
+			if err := teardowner.Teardown(t); err != nil {
+				t.Errorf("could not tear down %s: %v", what, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package gorkin
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// undefinedStep is a line of a feature which matches no step
+// definition.
+type undefinedStep struct {
+	// Line is the step's line, including its keyword.
+	Line string
+	// LineNum is the line's number within its feature file.
+	LineNum int
+	// Location is the feature file and line number of the step.
+	Location string
+	// DocString records whether a doc string follows the step.
+	DocString bool
+	// Suggestions are the step definitions the step may have been
+	// meant to match.
+	Suggestions []*stepDefinition
+}
+
+// undefinedStepsError reports the steps of a feature which match no
+// step definition.
+type undefinedStepsError struct {
+	Steps []*undefinedStep
+}
+
+func (e *undefinedStepsError) Error() string {
+	return "Please implement the missing runners."
+}
+
+// snippetArgRegex finds the parts of a step which are likely to be
+// arguments: quoted strings and numbers.
+var snippetArgRegex = regexp.MustCompile(`"[^"]*"|'[^']*'|-?\d*\.\d+|-?\d+`)
+
+// snippet returns a call to Step, ready to be pasted into a test,
+// which would match step. Quoted strings and numbers in the step
+// become parameters of a Cucumber Expression; steps without any are
+// matched by a regex. stepType is the pointer type of the isolation
+// value, which the step's function accepts first.
+func snippet(step *undefinedStep, stepType reflect.Type) string {
+	text := stepText(step.Line)
+	params := []string{isolationParam(stepType)}
+
+	expr, goTypes := snippetExpression(text)
+	quoted := quotePattern(expr)
+	if len(goTypes) == 0 {
+		quoted = quotePattern(regexp.QuoteMeta(text))
+	}
+	for _, goType := range goTypes {
+		params = append(params, fmt.Sprintf("arg%d %s", len(params), goType))
+	}
+	if step.DocString {
+		params = append(params, "doc string")
+	}
+
+	return fmt.Sprintf("Step(%s, func(%s) {\n\t// Write the code which makes this step pass here.\n})\n",
+		quoted,
+		strings.Join(params, ", "),
+	)
+}
+
+// snippetExpression returns a Cucumber Expression which matches text,
+// with its quoted strings and numbers replaced by parameters, and the
+// Go type of each parameter.
+func snippetExpression(text string) (string, []string) {
+	runes := []rune(text)
+	expr := new(bytes.Buffer)
+	var goTypes []string
+	last := 0
+	for _, loc := range snippetArgRegex.FindAllStringIndex(text, -1) {
+		// FindAllStringIndex works in bytes.
+		start := len([]rune(text[:loc[0]]))
+		end := len([]rune(text[:loc[1]]))
+		if !isArgAt(runes, start, end) {
+			continue
+		}
+
+		arg := string(runes[start:end])
+		name, goType := "string", "string"
+		switch {
+		case arg[0] == '"' || arg[0] == '\'':
+		case strings.Contains(arg, "."):
+			name, goType = "float", "float64"
+		default:
+			name, goType = "int", "int"
+		}
+
+		expr.WriteString(escapeExpression(string(runes[last:start])))
+		fmt.Fprintf(expr, "{%s}", name)
+		goTypes = append(goTypes, goType)
+		last = end
+	}
+	expr.WriteString(escapeExpression(string(runes[last:])))
+	return expr.String(), goTypes
+}
+
+// snippetsFor returns the snippets for steps, leaving out any which
+// would be the same as one before it.
+func snippetsFor(steps []*undefinedStep, stepType reflect.Type) string {
+	seen := make(map[string]bool)
+	out := new(bytes.Buffer)
+	for _, step := range steps {
+		s := snippet(step, stepType)
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(s)
+	}
+	return out.String()
+}
+
+// reportSnippets fails t with the snippets for the steps of the
+// features at paths which match no step definition, so that go test
+// shows them.
+func reportSnippets(reg *Registry, t *testing.T, paths []string, stepType reflect.Type) {
+	var undefined []*undefinedStep
+	for _, path := range paths {
+		ftr, err := readFeature(reg, path)
+		reg.format.FeatureFinished(ftr.Info)
+		if u, ok := err.(*undefinedStepsError); ok {
+			undefined = append(undefined, u.Steps...)
+		}
+	}
+
+	if len(undefined) == 0 {
+		t.Log("Every step is defined.")
+		return
+	}
+	message := suggestionsMessage(undefined)
+	if message != "" {
+		message += "\n"
+	}
+	t.Errorf("\n%sYou can implement the missing steps with these snippets:\n\n%s",
+		message,
+		snippetsFor(undefined, stepType),
+	)
+}
+
+// isArgAt reports whether text[start:end] stands apart from the words
+// around it, so that e.g. the 2 of "mp3" isn't treated as a number.
+func isArgAt(text []rune, start, end int) bool {
+	isWord := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+	}
+	return (start == 0 || !isWord(text[start-1])) && (end == len(text) || !isWord(text[end]))
+}
+
+// isolationParam declares the parameter which receives the isolation
+// value, named after its type.
+func isolationParam(stepType reflect.Type) string {
+	named := stepType
+	if named.Kind() == reflect.Ptr {
+		named = named.Elem()
+	}
+	if named.Name() == "" {
+		return "i " + stepType.String()
+	}
+
+	typeName := named.Name()
+	if stepType.Kind() == reflect.Ptr {
+		typeName = "*" + typeName
+	}
+	return strings.ToLower(named.Name()[:1]) + " " + typeName
+}
+
+// escapeExpression escapes the characters which are special to
+// Cucumber Expressions.
+func escapeExpression(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`(`, `\(`,
+		`)`, `\)`,
+		`{`, `\{`,
+		`}`, `\}`,
+		`/`, `\/`,
+	).Replace(s)
+}
+
+// quotePattern quotes pattern as a raw string where it can, since
+// patterns are full of backslashes.
+func quotePattern(pattern string) string {
+	if strings.Contains(pattern, "`") {
+		return strconv.Quote(pattern)
+	}
+	return "`" + pattern + "`"
+}
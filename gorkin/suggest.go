@@ -0,0 +1,97 @@
+package gorkin
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxSuggestions is the most step definitions suggested for an
+// undefined step.
+const maxSuggestions = 3
+
+// suggestions returns the step definitions whose patterns are closest
+// to text, a step which matched none of them, closest first. Patterns
+// are compared with both text itself and text with its arguments
+// replaced by parameters, so that `they should see {int} examples`
+// is suggested for "they should see 3 example".
+func (r *Registry) suggestions(text string) []*stepDefinition {
+	expr, _ := snippetExpression(text)
+
+	type candidate struct {
+		def      *stepDefinition
+		distance int
+	}
+	var candidates []candidate
+	for _, def := range r.steps {
+		distance := editDistance(text, def.Pattern)
+		if d := editDistance(expr, def.Pattern); d < distance {
+			distance = d
+		}
+		// Allow roughly one typo in every four characters.
+		if distance <= utf8.RuneCountInString(def.Pattern)/4+1 {
+			candidates = append(candidates, candidate{def, distance})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	var defs []*stepDefinition
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		defs = append(defs, candidates[i].def)
+	}
+	return defs
+}
+
+// suggestionsMessage lists the step definitions which might have been
+// meant by each of steps, or returns "" if there are none.
+func suggestionsMessage(steps []*undefinedStep) string {
+	msg := new(bytes.Buffer)
+	for _, step := range steps {
+		if len(step.Suggestions) == 0 {
+			continue
+		}
+		fmt.Fprintf(msg, "\n%s: %s\n\tDid you mean:", step.Location, step.Line)
+		for _, def := range step.Suggestions {
+			fmt.Fprintf(msg, "\n\t\t`%s` (%s)", def.Pattern, def.Location())
+		}
+		msg.WriteString("\n")
+	}
+	return msg.String()
+}
+
+// editDistance returns the Levenshtein distance between a and b: the
+// number of runes which must be inserted, deleted or substituted to
+// turn one into the other.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,160 @@
+package gorkin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tagExpression is a parsed tag expression such as
+// "@db and not @readonly".
+type tagExpression interface {
+	// matches reports whether the expression holds for tags.
+	matches(tags []string) bool
+	String() string
+}
+
+type tagLiteral string
+
+func (l tagLiteral) matches(tags []string) bool {
+	for _, tag := range tags {
+		if tag == string(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l tagLiteral) String() string { return string(l) }
+
+type tagNot struct{ x tagExpression }
+
+func (n tagNot) matches(tags []string) bool { return !n.x.matches(tags) }
+func (n tagNot) String() string             { return fmt.Sprintf("not (%s)", n.x) }
+
+type tagAnd struct{ left, right tagExpression }
+
+func (a tagAnd) matches(tags []string) bool { return a.left.matches(tags) && a.right.matches(tags) }
+func (a tagAnd) String() string             { return fmt.Sprintf("(%s and %s)", a.left, a.right) }
+
+type tagOr struct{ left, right tagExpression }
+
+func (o tagOr) matches(tags []string) bool { return o.left.matches(tags) || o.right.matches(tags) }
+func (o tagOr) String() string             { return fmt.Sprintf("(%s or %s)", o.left, o.right) }
+
+// parseTagExpression parses a tag expression made of tags, "and", "or",
+// "not" and parentheses. "not" binds tightest and "or" loosest.
+func parseTagExpression(expr string) (tagExpression, error) {
+	p := &tagParser{tokens: tagTokens(expr)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty tag expression")
+	}
+	x, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("tag expression %q: %v", expr, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("tag expression %q: unexpected %q", expr, p.tokens[p.pos])
+	}
+	return x, nil
+}
+
+// tagTokens splits expr into words and parentheses.
+func tagTokens(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+// tagParser is a recursive descent parser over the tokens of a tag
+// expression.
+type tagParser struct {
+	tokens []string
+	pos    int
+}
+
+// next consumes and returns the next token, or "" at the end.
+func (p *tagParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	p.pos++
+	return p.tokens[p.pos-1]
+}
+
+// peek returns the next token without consuming it.
+func (p *tagParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *tagParser) or() (tagExpression, error) {
+	left, err := p.and()
+	for err == nil && p.peek() == "or" {
+		p.next()
+		var right tagExpression
+		if right, err = p.and(); err == nil {
+			left = tagOr{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *tagParser) and() (tagExpression, error) {
+	left, err := p.not()
+	for err == nil && p.peek() == "and" {
+		p.next()
+		var right tagExpression
+		if right, err = p.not(); err == nil {
+			left = tagAnd{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *tagParser) not() (tagExpression, error) {
+	if p.peek() != "not" {
+		return p.primary()
+	}
+	p.next()
+	x, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	return tagNot{x}, nil
+}
+
+func (p *tagParser) primary() (tagExpression, error) {
+	switch token := p.next(); {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing != ")" {
+			return nil, fmt.Errorf("expected \")\" but found %q", closing)
+		}
+		return x, nil
+	case strings.HasPrefix(token, "@") && len(token) > 1:
+		return tagLiteral(token), nil
+	default:
+		return nil, fmt.Errorf("expected a tag but found %q", token)
+	}
+}
+
+// parseTags returns the tags on a line of a feature such as
+// "@db @slow". A comment may follow the tags.
+func parseTags(line string) ([]string, error) {
+	if comment := strings.Index(line, " #"); comment >= 0 {
+		line = line[:comment]
+	}
+	tags := strings.Fields(line)
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, "@") || len(tag) == 1 {
+			return nil, fmt.Errorf("%q is not a tag", tag)
+		}
+	}
+	return tags, nil
+}
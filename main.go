@@ -1,20 +1,32 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
-	"os"
-	"flag"
-	
 )
 
 const version = "0.0.1"
 
+const commandUsage = `
+Commands:
+  run             Run the features against their steps. This is the default.
+    -dry-run      Match every step and check its arguments without running it.
+    -format       Report with a formatter, e.g. text or pretty:out.txt. May be repeated.
+    -no-color     Don't colour the output of the pretty formatter.
+    -color        Colour the output of the pretty formatter even when it isn't a terminal.
+  steps check     Report steps whose patterns can match the same text.
+  steps snippets  Print snippets for steps which have no definition.
+  steps unused    Run the features and fail if any step matched no line.
+`
+
 func main() {
 
 	var (
-		help = flag.Bool("help", false, "Get usage on gorkin.")
+		help       = flag.Bool("help", false, "Get usage on gorkin.")
 		initialize = flag.Bool("init", false, "Initialize a Gherkin structure.")
 	)
 
@@ -22,7 +34,10 @@ func main() {
 
 	if *help {
 		fmt.Fprintf(os.Stderr, "gorkin v%s:\n", version)
-		flag.PrintDefaults()
+		flag.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(os.Stderr, "  -%s=%s: %s\n", f.Name, f.DefValue, f.Usage)
+		})
+		fmt.Fprint(os.Stderr, commandUsage)
 		return
 	}
 
@@ -35,17 +50,109 @@ func main() {
 		return
 	}
 
+	var testArgs []string
+	args := flag.Args()
+	switch command := strings.Join(args, " "); {
+	case len(args) == 0 || args[0] == "run":
+		runFlags := flag.NewFlagSet("run", flag.ExitOnError)
+		dryRun := runFlags.Bool("dry-run", false, "Match steps without running them.")
+		var formats repeatedFlag
+		runFlags.Var(&formats, "format", "Report with a formatter.")
+		noColor := runFlags.Bool("no-color", false, "Don't colour the output.")
+		color := runFlags.Bool("color", false, "Colour the output even when it isn't a terminal.")
+		if len(args) > 0 {
+			runFlags.Parse(args[1:])
+		}
+		if runFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n", command)
+			return
+		}
+		if *dryRun {
+			testArgs = append(testArgs, "-gorkin.dry-run")
+		}
+		if *noColor {
+			testArgs = append(testArgs, "-gorkin.no-color")
+		}
+		if *color {
+			testArgs = append(testArgs, "-gorkin.color")
+		}
+		for _, format := range formats {
+			// The tests run in the steps directory, so a report's path
+			// is made absolute against the user's.
+			if colon := strings.Index(format, ":"); colon >= 0 && colon < len(format)-1 {
+				path, err := filepath.Abs(format[colon+1:])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "invalid format %q: %v\n", format, err)
+					return
+				}
+				format = format[:colon+1] + path
+			}
+			testArgs = append(testArgs, "-gorkin.format="+format)
+		}
+	case command == "steps check":
+		testArgs = append(testArgs, "-gorkin.check-steps")
+	case command == "steps snippets":
+		testArgs = append(testArgs, "-gorkin.snippets")
+	case command == "steps unused":
+		testArgs = append(testArgs, "-gorkin.fail-unused")
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", command)
+		return
+	}
+
 	if _, err := os.Stat("features"); os.IsNotExist(err) {
 		fmt.Printf("could not find a features directory.")
 		return
 	}
-	
-	cmd := exec.Command("go", "test", "./features/steps/...")
-	out, err := cmd.CombinedOutput()
+
+	runSteps(testArgs...)
+}
+
+// repeatedFlag collects the values of a flag which may be repeated.
+type repeatedFlag []string
+
+func (f *repeatedFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *repeatedFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// runSteps runs the tests in each package under the steps directory,
+// passing testArgs through to the gorkin package. Each package is
+// tested from its own directory so that go test streams its output,
+// including that of passing runs, rather than summarising it.
+func runSteps(testArgs ...string) {
+	list := exec.Command("go", "list", "-f", "{{.Dir}}", "./features/steps/...")
+	list.Stderr = os.Stderr
+	dirs, err := list.Output()
 	if err != nil {
-		fmt.Printf(`error running "%s": %s`, strings.Join(cmd.Args, " "), string(out))
-		return
+		fmt.Printf(`error running "%s": %v`, strings.Join(list.Args, " "), err)
+		os.Exit(1)
+	}
+
+	args := []string{"test"}
+	if len(testArgs) > 0 {
+		args = append(append(args, "-args"), testArgs...)
 	}
 
-	fmt.Print(string(out))
+	exitCode := 0
+	for _, dir := range strings.Split(strings.TrimSpace(string(dirs)), "\n") {
+		if dir == "" {
+			continue
+		}
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				fmt.Printf(`error running "%s": %v`, strings.Join(cmd.Args, " "), err)
+				os.Exit(1)
+			}
+			exitCode = exitErr.ExitCode()
+		}
+	}
+	os.Exit(exitCode)
 }